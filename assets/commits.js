@@ -7,7 +7,53 @@ document.addEventListener('DOMContentLoaded', () => {
     const authorFilter = document.getElementById('author-filter');
     const timeframeFilter = document.getElementById('timeframe-filter');
     const categoryFilter = document.getElementById('category-filter');
+    const typeFilter = document.getElementById('type-filter');
     const srcUrl = document.body.dataset.sourceCodeUrl || 'https://codeberg.org/derivelinux/ports';
+    const pagesUrl = (container.dataset.commitPagesUrl || '').replace(/index\.html$/, '');
+    const pagesSince = parseInt(container.dataset.commitPagesSince || '0', 10) * 1000;
+
+    const contributorsUrl = container.dataset.contributorsUrl || (baseUrl + '/contributors.json');
+    const contributorsBase = contributorsUrl.replace(/contributors\.json$/, 'contributors/');
+    let slugs = {};
+
+    const escapes = { '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' };
+    function esc(s) {
+        return String(s ?? '').replace(/[&<>"']/g, ch => escapes[ch]);
+    }
+
+    function authorHtml(c) {
+        const name = esc(c.author || 'unknown');
+        const slug = slugs[(c.email || '').trim().toLowerCase()];
+        if (slug) return `<a href="${contributorsBase}${esc(slug)}/index.html" class="commit-author">${name}</a>`;
+        return `<span class="commit-author">${name}</span>`;
+    }
+
+    function trailersHtml(c) {
+        const person = (key, p) => {
+            const slug = slugs[(p.email || '').trim().toLowerCase()];
+            const name = slug ? `<a href="${contributorsBase}${esc(slug)}/index.html">${esc(p.name)}</a>` : esc(p.name);
+            return `<span class="commit-trailer"><span class="trailer-key">${key}:</span> ${name}</span>`;
+        };
+        const parts = [
+            ...(c.co_authors || []).map(p => person('Co-authored-by', p)),
+            ...(c.reviewed_by || []).map(p => person('Reviewed-by', p)),
+            ...(c.signed_off_by || []).map(p => person('Signed-off-by', p)),
+            ...(c.fixes || []).map(f => `<span class="commit-trailer"><span class="trailer-key">Fixes:</span> ${esc(f)}</span>`)
+        ];
+        return parts.length > 0 ? `<div class="commit-trailers">${parts.join('')}</div>` : '';
+    }
+
+    const sigLabels = { valid: 'verified', unknown_key: 'unknown key', bad: 'bad signature', unsigned: 'unsigned' };
+
+    function signatureHtml(c) {
+        if (!c.signature) return '';
+        return `<span class="sig-badge sig-${esc(c.signature)}" title="${esc(c.signed_by)}">${esc(sigLabels[c.signature] || c.signature)}</span>`;
+    }
+
+    function commitUrl(c) {
+        if (pagesUrl && new Date(c.date).getTime() > pagesSince) return `${pagesUrl}${c.hash}/index.html`;
+        return `${srcUrl}/commit/${c.hash}`;
+    }
 
     let allCommits = [];
 
@@ -21,15 +67,15 @@ document.addEventListener('DOMContentLoaded', () => {
             const date = new Date(c.date);
             const dateStr = isNaN(date) ? '---' : date.toISOString().slice(0,16).replace('T',' ') + ' UTC';
             const lines = (c.message || '').split('\n');
-            const firstLine = lines[0] || '';
-            const rest = lines.slice(1).join('<br/>').trim();
+            const firstLine = esc(lines[0] || '');
+            const rest = lines.slice(1).map(esc).join('<br/>').trim();
             const shortHash = (c.hash || '').slice(0,7);
             
             let filesHtml = '';
             const allFiles = [
-                ...(c.added_files || []).map(f => `<span class="file-added">${f}</span>`),
-                ...(c.modified_files || []).map(f => `<span class="file-modified">${f}</span>`),
-                ...(c.deleted_files || []).map(f => `<span class="file-deleted">${f}</span>`)
+                ...(c.added_files || []).map(f => `<span class="file-added">${esc(f)}</span>`),
+                ...(c.modified_files || []).map(f => `<span class="file-modified">${esc(f)}</span>`),
+                ...(c.deleted_files || []).map(f => `<span class="file-deleted">${esc(f)}</span>`)
             ];
             if (allFiles.length > 0) {
                 filesHtml = `<div class="commit-files">${allFiles.join('')}</div>`;
@@ -40,10 +86,13 @@ document.addEventListener('DOMContentLoaded', () => {
                     <div class="commit-header">
                         <span class="commit-hash">${shortHash}</span>
                         <span class="commit-time">${dateStr}</span>
-                        <span class="commit-author">${c.author || 'unknown'}</span>
+                        ${authorHtml(c)}
+                        ${signatureHtml(c)}
+                        ${c.type ? `<span class="commit-type" title="${esc(c.violation)}">${esc(c.type)}${c.violation ? ' !' : ''}</span>` : ''}
                     </div>
-                    <div class="commit-title"><a href="${srcUrl}/commit/${c.hash}" target="_blank">${firstLine}</a></div>
+                    <div class="commit-title"><a href="${commitUrl(c)}">${firstLine}</a></div>
                     ${rest ? `<div class="commit-msg">${rest}</div>` : ''}
+                    ${trailersHtml(c)}
                     ${filesHtml}
                 </div>
             `;
@@ -54,10 +103,13 @@ document.addEventListener('DOMContentLoaded', () => {
         const author = authorFilter?.value;
         const timeframe = timeframeFilter?.value;
         const category = categoryFilter?.value;
+        const type = typeFilter?.value;
 
         let filtered = allCommits;
-        if (author) filtered = filtered.filter(c => c.author === author);
+        if (author) filtered = filtered.filter(c => c.author === author || (c.co_authors || []).some(p => p.name === author));
         
+        if (type) filtered = filtered.filter(c => c.type === type);
+
         if (category) {
             filtered = filtered.filter(c => {
                 const files = [...(c.added_files || []), ...(c.modified_files || []), ...(c.deleted_files || [])];
@@ -77,7 +129,11 @@ document.addEventListener('DOMContentLoaded', () => {
         render(filtered);
     }
 
-    fetch(url)
+    fetch(contributorsUrl)
+        .then(r => r.ok ? r.json() : {})
+        .then(data => { slugs = data || {}; })
+        .catch(() => {})
+        .then(() => fetch(url))
         .then(r => {
             if (!r.ok) throw new Error(`HTTP ${r.status}`);
             return r.json();
@@ -85,12 +141,13 @@ document.addEventListener('DOMContentLoaded', () => {
         .then(data => {
             allCommits = (data || []).filter(c => c && c.hash);
             if (authorFilter) {
-                const authors = [...new Set(allCommits.map(c => c.author))].filter(Boolean).sort();
+                const authors = [...new Set(allCommits.flatMap(c => [c.author, ...(c.co_authors || []).map(p => p.name)]))].filter(Boolean).sort();
                 authorFilter.innerHTML = '<option value="">all authors</option>' + 
                     authors.map(a => `<option value="${a}">${a}</option>`).join('');
                 authorFilter.addEventListener('change', applyFilters);
             }
             if (categoryFilter) categoryFilter.addEventListener('change', applyFilters);
+            typeFilter?.addEventListener('change', applyFilters);
             timeframeFilter?.addEventListener('change', applyFilters);
             render(allCommits);
         })
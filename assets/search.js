@@ -17,6 +17,8 @@ document.addEventListener('DOMContentLoaded', () => {
 
     let portsData = [];
     const baseUrl = document.body.dataset.baseUrl || '';
+    const statuses = JSON.parse(searchForm.dataset.statuses || '{}');
+    const statusOf = (name) => statuses[name] || { name: name, label: name, color: 'orange', icon: '?', kind: 'neutral' };
 
     // Load ports data
     fetch(baseUrl + '/ports.json')
@@ -48,6 +50,39 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     });
 
+    // file: filters are answered from the file index under /files/, split
+    // into shards by the FNV-1a hash of the lowercased basename.
+    const fileShards = {};
+
+    function fileBase(path) {
+        return path.substring(path.lastIndexOf('/') + 1);
+    }
+
+    function fileShard(base) {
+        let h = 0x811c9dc5;
+        for (const b of new TextEncoder().encode(base)) {
+            h ^= b;
+            h = Math.imul(h, 0x01000193) >>> 0;
+        }
+        return (h % 256).toString(16).padStart(2, '0');
+    }
+
+    function missingFileShards(query) {
+        const shards = new Set();
+        for (const m of query.matchAll(/file:(\S+)/g)) {
+            const shard = fileShard(fileBase(m[1]));
+            if (!(shard in fileShards)) shards.add(shard);
+        }
+        return [...shards];
+    }
+
+    function loadFileShard(shard) {
+        return fetch(baseUrl + '/files/' + shard + '.json')
+            .then(r => r.ok ? r.json() : {})
+            .catch(() => ({}))
+            .then(data => { fileShards[shard] = data || {}; });
+    }
+
     function performSearch(query) {
         const startTime = performance.now();
         const rawQuery = query.toLowerCase().trim();
@@ -57,6 +92,12 @@ document.addEventListener('DOMContentLoaded', () => {
             return;
         }
 
+        const missing = missingFileShards(rawQuery);
+        if (missing.length) {
+            Promise.all(missing.map(loadFileShard)).then(() => performSearch(query));
+            return;
+        }
+
         let filtered = portsData;
 
         if (rawQuery !== '*') {
@@ -96,9 +137,23 @@ document.addEventListener('DOMContentLoaded', () => {
         else if (text.startsWith('desc:') || text.startsWith('description:')) match = p.d.toLowerCase().includes(text.substring(text.indexOf(':') + 1));
         else if (text.startsWith('cat:') || text.startsWith('category:')) match = p.c.toLowerCase() === text.substring(text.indexOf(':') + 1);
         else if (text.startsWith('dep:') || text.startsWith('depends:')) match = p.dps && p.dps.some(d => d.toLowerCase().includes(text.substring(text.indexOf(':') + 1)));
+        else if (text.startsWith('file:')) {
+            let path = text.substring(5);
+            const base = fileBase(path);
+            if (path.includes('/') && !path.startsWith('/')) path = '/' + path;
+            const hits = (fileShards[fileShard(base)] || {})[base] || [];
+            const key = p.c + '/' + p.n;
+            match = hits.some(h => h[1] === key && (!path.includes('/') || h[0].toLowerCase() === path));
+        }
+        else if (text.startsWith('status:')) {
+            const val = text.substring(7);
+            match = !!p.st && (p.st.toLowerCase() === val || statusOf(p.st).kind === val);
+        }
+        else if (text.startsWith('soname:')) match = p.so && p.so.some(s => s.toLowerCase() === text.substring(7));
         else if (text.startsWith('provides:')) match = p.pds && p.pds.some(f => f.toLowerCase().includes(text.substring(9)));
-        else if (text === 'is:broken') match = p.br || p.st === 'failed';
+        else if (text === 'is:broken') match = p.br || (!!p.st && statusOf(p.st).kind === 'failure');
         else if (text === 'is:unmaintained') match = p.un;
+        else if (text === 'is:regressed') match = p.rg;
         else if (text === 'is:new') {
             const thirtyDaysAgo = (Date.now() / 1000) - (30 * 86400000 / 1000);
             match = p.dt > thirtyDaysAgo;
@@ -145,15 +200,16 @@ document.addEventListener('DOMContentLoaded', () => {
             const portUrl = baseUrl + '/ports/' + p.c + '/' + p.n + '/index.html';
             const catUrl = baseUrl + '/categories/' + p.c + '/index.html';
             
-            let statusClass = 'none';
-            if (p.st === 'success') statusClass = 'success';
-            else if (p.st === 'failed') statusClass = 'failed';
-            else if (p.br) statusClass = 'failed';
+            let statusDot = '<span class="status-dot none"></span>';
+            if (p.st) {
+                const s = statusOf(p.st);
+                statusDot = `<span class="status-dot" style="background-color: ${s.color}" title="${s.icon} ${s.label}"></span>`;
+            } else if (p.br) statusDot = '<span class="status-dot failed"></span>';
 
             row.innerHTML = `
                 <td>
                     <div class="flex-center">
-                        <span class="status-dot ${statusClass}"></span>
+                        ${statusDot}
                         <a href="${portUrl}" class="port-name">${p.n}</a>
                     </div>
                 </td>
@@ -3,18 +3,21 @@ module portsMaster
 go 1.25.5
 
 require (
+	github.com/ProtonMail/go-crypto v1.3.0
 	github.com/a-h/templ v0.3.977
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/go-git/go-git/v5 v5.16.4
+	github.com/klauspost/compress v1.20.1
 	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/tdewolff/minify/v2 v2.24.8
+	github.com/ulikunitz/xz v0.5.17
+	golang.org/x/crypto v0.47.0
 	lukechampine.com/blake3 v1.4.1
 )
 
 require (
 	dario.cat/mergo v1.0.2 // indirect
 	github.com/Microsoft/go-winio v0.6.2 // indirect
-	github.com/ProtonMail/go-crypto v1.3.0 // indirect
 	github.com/cloudflare/circl v1.6.2 // indirect
 	github.com/cyphar/filepath-securejoin v0.6.1 // indirect
 	github.com/emirpasic/gods v1.18.1 // indirect
@@ -29,7 +32,6 @@ require (
 	github.com/skeema/knownhosts v1.3.2 // indirect
 	github.com/tdewolff/parse/v2 v2.8.5 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
@@ -7,21 +7,35 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"portsMaster/pkg/build"
 	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+	"portsMaster/pkg/util"
 
 	"github.com/fsnotify/fsnotify"
 )
 
 func main() {
+	cmd, args := "build", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	var operands []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		operands, args = append(operands, args[0]), args[1:]
+	}
+
 	cfg := config.New()
 
-	configPath, err := cfg.ParseFlags(os.Args[1:])
+	configPath, err := cfg.ParseFlags(args)
 	if err != nil {
 		log.Fatalf("fatal: %v", err)
 	}
@@ -30,19 +44,43 @@ func main() {
 		log.Fatalf("fatal: %v", err)
 	}
 
-	if _, err := cfg.ParseFlags(os.Args[1:]); err != nil {
+	if _, err := cfg.ParseFlags(args); err != nil {
 		log.Fatalf("fatal: %v", err)
 	}
 
 	cfg.Finalize()
 
+	if _, err := syncPorts(cfg); err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+
+	switch cmd {
+	case "build":
+		runBuild(cfg, configPath)
+	case "changelog":
+		runChangelog(cfg, operands)
+	case "ci":
+		runCI(cfg, operands)
+	case "repo":
+		runRepo(cfg, operands)
+	default:
+		log.Fatalf("fatal: unknown command %q", cmd)
+	}
+}
+
+func runBuild(cfg *config.Config, configPath string) {
 	engine, err := build.New(cfg)
 	if err != nil {
 		log.Fatalf("fatal: %v", err)
 	}
 
+	// The watcher swaps in a new engine for every rebuild; the server
+	// hands CI results to the current one.
+	var current atomic.Pointer[build.Engine]
+	current.Store(engine)
+
 	if cfg.Serve {
-		startServer(cfg, engine.Ready)
+		startServer(cfg, engine.Ready, current.Load)
 	}
 
 	ctx := context.Background()
@@ -50,25 +88,138 @@ func main() {
 		log.Printf("error: %v", err)
 	}
 
-	if cfg.Watch {
-		runWatcher(cfg, configPath)
+	if cfg.Watch && cfg.Rev == "" {
+		runWatcher(cfg, configPath, &current)
 	} else if cfg.Serve {
 		select {}
 	}
 }
 
-func startServer(cfg *config.Config, ready chan struct{}) {
+// runChangelog prints the release notes for a "<from>..<to>" range. A bare
+// ref lists everything up to it.
+func runChangelog(cfg *config.Config, operands []string) {
+	if len(operands) != 1 {
+		log.Fatalf("usage: portsMaster changelog <from>..<to> [-format md|html|json] [-output file]")
+	}
+	if strings.Contains(operands[0], "...") {
+		log.Fatalf("fatal: symmetric difference ranges (a...b) are not supported, use <from>..<to>")
+	}
+	from, to, ok := strings.Cut(operands[0], "..")
+	if !ok {
+		from, to = "", operands[0]
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	engine, err := build.New(cfg)
+	if err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+	cl, err := engine.Changelog(context.Background(), from, to)
+	if err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+
+	out := os.Stdout
+	if cfg.Output != "" {
+		f, err := os.Create(cfg.Output)
+		if err != nil {
+			log.Fatalf("fatal: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := build.WriteChangelog(out, cl, cfg.Format, cfg); err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+}
+
+// runCI handles "ci run": it builds every port and writes ci_status.json.
+func runCI(cfg *config.Config, operands []string) {
+	if len(operands) != 1 || operands[0] != "run" {
+		log.Fatalf("usage: portsMaster ci run [-output ci_status.json]")
+	}
+	engine, err := build.New(cfg)
+	if err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	path, results, err := engine.RunCI(ctx, func(key string, info *model.CIInfo) {
+		log.Printf("ci: %-30s %-8s %s", key, info.Status, util.FormatDuration(info.BuildDuration))
+	})
+	if err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, info := range results {
+		counts[info.Status]++
+	}
+	var summary []string
+	for _, s := range cfg.CI.Vocabulary.Sorted() {
+		if n := counts[s.Name]; n > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", n, s.Name))
+		}
+	}
+	log.Printf("ci: %d ports (%s); results in %s", len(results), strings.Join(summary, ", "), path)
+}
+
+// runRepo handles "repo index": it writes the package repository index.
+func runRepo(cfg *config.Config, operands []string) {
+	if len(operands) != 1 || operands[0] != "index" {
+		log.Fatalf("usage: portsMaster repo index [-output dir]")
+	}
+	engine, err := build.New(cfg)
+	if err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+	dir, n, err := engine.RepoIndex(context.Background())
+	if err != nil {
+		log.Fatalf("fatal: %v", err)
+	}
+	log.Printf("repo: indexed %s in %s", util.Plural(n, "package"), dir)
+}
+
+// syncPorts clones or fetches the ports tree, along with the refs of the
+// extra branches, when ports_path is a git URL.
+func syncPorts(cfg *config.Config) (bool, error) {
+	if cfg.PortsURL == "" {
+		return false, nil
+	}
+	refs := make([]string, 0, len(cfg.Branches))
+	for _, b := range cfg.Branches {
+		refs = append(refs, b.Ref)
+	}
+	changed, err := source.SyncRepository(cfg.PortsURL, cfg.PortsPath, cfg.Remote.Branch, refs, cfg.Remote.Depth)
+	if changed {
+		log.Printf("remote: %s updated", cfg.PortsURL)
+	}
+	return changed, err
+}
+
+func startServer(cfg *config.Config, ready chan struct{}, engine func() *build.Engine) {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(cfg.OutDir)))
+	if cfg.API.Token != "" {
+		mux.Handle("/api/ci", build.NewCIHandler(cfg.API.Token, engine))
+	}
+
 	go func() {
 		<-ready
 		log.Printf("server: listening on http://localhost%s", cfg.ServeAddr)
-		fs := http.FileServer(http.Dir(cfg.OutDir))
-		if err := http.ListenAndServe(cfg.ServeAddr, fs); err != nil {
+		if cfg.API.Token != "" {
+			log.Printf("server: accepting CI results on /api/ci")
+		}
+		if err := http.ListenAndServe(cfg.ServeAddr, mux); err != nil {
 			log.Printf("error: server: %v", err)
 		}
 	}()
 }
 
-func runWatcher(cfg *config.Config, configPath string) {
+func runWatcher(cfg *config.Config, configPath string, current *atomic.Pointer[build.Engine]) {
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
 		log.Fatal(err)
@@ -103,6 +254,13 @@ func runWatcher(cfg *config.Config, configPath string) {
 	timer := time.NewTimer(time.Hour)
 	timer.Stop()
 
+	var fetch <-chan time.Time
+	if cfg.PortsURL != "" {
+		ticker := time.NewTicker(cfg.FetchEvery())
+		defer ticker.Stop()
+		fetch = ticker.C
+	}
+
 	for {
 		select {
 		case event, ok := <-w.Events:
@@ -115,6 +273,13 @@ func runWatcher(cfg *config.Config, configPath string) {
 				}
 				timer.Reset(300 * time.Millisecond)
 			}
+		case <-fetch:
+			changed, err := syncPorts(cfg)
+			if err != nil {
+				log.Printf("watcher: %v", err)
+			} else if changed {
+				timer.Reset(300 * time.Millisecond)
+			}
 		case <-timer.C:
 			log.Println("watcher: changes detected, rebuilding...")
 			if isDev {
@@ -123,7 +288,12 @@ func runWatcher(cfg *config.Config, configPath string) {
 				}
 				return
 			}
-			engine, _ := build.New(cfg)
+			engine, err := build.New(cfg)
+			if err != nil {
+				log.Printf("watcher: %v", err)
+				continue
+			}
+			current.Store(engine)
 			engine.Run(context.Background())
 		}
 	}
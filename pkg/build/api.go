@@ -0,0 +1,127 @@
+package build
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+// maxCIBody bounds the size of a request to the CI endpoint.
+const maxCIBody = 8 << 20
+
+type ciResponse struct {
+	Updated []string `json:"updated,omitempty"`
+	Unknown []string `json:"unknown,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// NewCIHandler serves POST /api/ci. The body is either an object mapping
+// "category/name" to results, in the format of ci_status.json, or a single
+// result for the port named by the port query parameter. Requests must
+// carry "Authorization: Bearer <token>". engine returns the engine of the
+// latest build.
+func NewCIHandler(token string, engine func() *Engine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeCIResponse(w, http.StatusMethodNotAllowed, ciResponse{Error: "method not allowed"})
+			return
+		}
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="portsMaster"`)
+			writeCIResponse(w, http.StatusUnauthorized, ciResponse{Error: "invalid token"})
+			return
+		}
+
+		results, err := decodeCIResults(w, r)
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeCIResponse(w, status, ciResponse{Error: err.Error()})
+			return
+		}
+
+		updated, unknown, err := engine().ApplyCI(r.Context(), results)
+		if err != nil {
+			writeCIResponse(w, http.StatusInternalServerError, ciResponse{Error: err.Error()})
+			return
+		}
+		writeCIResponse(w, http.StatusOK, ciResponse{Updated: updated, Unknown: unknown})
+	})
+}
+
+// decodeCIResults reads and validates the results of a request. Results
+// without a build start are stamped with the time they were received.
+func decodeCIResults(w http.ResponseWriter, r *http.Request) (map[string]*model.CIInfo, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCIBody))
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]*model.CIInfo)
+	if key := r.URL.Query().Get("port"); key != "" {
+		var info model.CIInfo
+		if err := json.Unmarshal(body, &info); err != nil {
+			return nil, err
+		}
+		results[key] = &info
+	} else if err := json.Unmarshal(body, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results")
+	}
+
+	now := time.Now().Unix()
+	for key, info := range results {
+		cat, name, ok := strings.Cut(key, "/")
+		if !ok || cat == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid port %q, want category/name", key)
+		}
+		if info == nil || info.Status == "" {
+			return nil, fmt.Errorf("%s: missing status", key)
+		}
+		if !relativeLog(info.BuildLog) {
+			return nil, fmt.Errorf("%s: build_log must be a URL or a path relative to log_root", key)
+		}
+		if info.BuildStarted == 0 {
+			info.BuildStarted = now
+		}
+	}
+	return results, nil
+}
+
+// relativeLog reports whether a pushed build log is a URL or a relative
+// path that stays below log_root.
+func relativeLog(log string) bool {
+	if log == "" || config.IsRemote(log) {
+		return true
+	}
+	if filepath.IsAbs(log) || strings.HasPrefix(log, "~") {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(log), "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCIResponse(w http.ResponseWriter, status int, resp ciResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
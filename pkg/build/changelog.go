@@ -0,0 +1,184 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+	"portsMaster/views"
+)
+
+// changelogVersion is bumped whenever the JSON layout of a changelog changes.
+const changelogVersion = 1
+
+// Changelog compares the ports tree at two git refs. An empty from compares
+// against an empty tree, so every port of to is listed as added.
+func (c *Collector) Changelog(ctx context.Context, from, to string) (*model.Changelog, error) {
+	gp, err := source.NewGitProvider(c.reg.PortsRoot())
+	if err != nil {
+		return nil, err
+	}
+	gp.SetMailmap(c.loadMailmap())
+	return c.changelog(ctx, gp, from, to)
+}
+
+// Changelog compares the ports tree at two git refs; see Collector.Changelog.
+func (e *Engine) Changelog(ctx context.Context, from, to string) (*model.Changelog, error) {
+	return NewCollector(e.cfg, e.reg, e.scanner).Changelog(ctx, from, to)
+}
+
+func (c *Collector) changelog(ctx context.Context, gp *source.GitProvider, from, to string) (*model.Changelog, error) {
+	cl := &model.Changelog{Version: changelogVersion, From: from, To: to}
+
+	var err error
+	if cl.ToHash, cl.Date, err = gp.Revision(to); err != nil {
+		return nil, err
+	}
+	toDB, err := c.scanRef(ctx, gp, to)
+	if err != nil {
+		return nil, err
+	}
+	fromDB := &model.Database{}
+	if from != "" {
+		if cl.FromHash, _, err = gp.Revision(from); err != nil {
+			return nil, err
+		}
+		if fromDB, err = c.scanRef(ctx, gp, from); err != nil {
+			return nil, err
+		}
+	}
+
+	commits, err := gp.CommitsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	cl.Sections = changelogSections(fromDB, toDB, commits)
+	return cl, nil
+}
+
+// changelogSections sorts the differences between two trees into release
+// note sections, each entry carrying the commits that touched the port.
+func changelogSections(from, to *model.Database, commits map[string][]*model.Commit) []model.ChangelogSection {
+	entry := func(p *model.Port, old, new string) model.ChangelogEntry {
+		return model.ChangelogEntry{
+			Category: p.Category,
+			Name:     p.Name,
+			Old:      old,
+			New:      new,
+			Commits:  commits[p.Category+"/"+p.Name],
+		}
+	}
+
+	added := model.ChangelogSection{Kind: "added", Title: "Added ports"}
+	removed := model.ChangelogSection{Kind: "removed", Title: "Removed ports"}
+	upgraded := model.ChangelogSection{Kind: "upgraded", Title: "Upgrades"}
+	downgraded := model.ChangelogSection{Kind: "downgraded", Title: "Downgrades"}
+	license := model.ChangelogSection{Kind: "license", Title: "License changes"}
+	maintainer := model.ChangelogSection{Kind: "maintainer", Title: "Maintainer changes"}
+	broken := model.ChangelogSection{Kind: "broken", Title: "Newly broken"}
+	fixed := model.ChangelogSection{Kind: "fixed", Title: "Fixed"}
+
+	cmp := compareDatabases("", "", from, to)
+	for _, p := range cmp.Added {
+		added.Entries = append(added.Entries, entry(p, "", versionOf(p)))
+	}
+	for _, p := range cmp.Removed {
+		removed.Entries = append(removed.Entries, entry(p, versionOf(p), ""))
+	}
+	for _, ch := range cmp.Changed {
+		e := entry(ch.To, versionOf(ch.From), versionOf(ch.To))
+		if ch.Direction > 0 {
+			upgraded.Entries = append(upgraded.Entries, e)
+		} else {
+			downgraded.Entries = append(downgraded.Entries, e)
+		}
+	}
+
+	old := make(map[string]*model.Port, len(from.Ports))
+	for _, p := range from.Ports {
+		old[p.Category+"/"+p.Name] = p
+	}
+	ports := append([]*model.Port{}, to.Ports...)
+	sortPorts(ports)
+	for _, p := range ports {
+		op, ok := old[p.Category+"/"+p.Name]
+		if !ok {
+			continue
+		}
+		if op.License != p.License {
+			license.Entries = append(license.Entries, entry(p, op.License, p.License))
+		}
+		if op.Maintainer != p.Maintainer {
+			maintainer.Entries = append(maintainer.Entries, entry(p, op.Maintainer, p.Maintainer))
+		}
+		if !op.IsBroken && p.IsBroken {
+			broken.Entries = append(broken.Entries, entry(p, "", ""))
+		}
+		if op.IsBroken && !p.IsBroken {
+			fixed.Entries = append(fixed.Entries, entry(p, "", ""))
+		}
+	}
+
+	sections := []model.ChangelogSection{added, removed, upgraded, downgraded, license, maintainer, broken, fixed}
+	for i := range sections {
+		if sections[i].Entries == nil {
+			sections[i].Entries = []model.ChangelogEntry{}
+		}
+	}
+	return sections
+}
+
+// WriteChangelog renders cl as Markdown ("md"), a standalone HTML page
+// ("html") or JSON ("json").
+func WriteChangelog(w io.Writer, cl *model.Changelog, format string, cfg *config.Config) error {
+	switch format {
+	case "", "md", "markdown":
+		return writeChangelogMarkdown(w, cl, cfg)
+	case "html":
+		return views.ChangelogDocument(cl, cfg).Render(context.Background(), w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cl)
+	default:
+		return fmt.Errorf("unknown changelog format %q", format)
+	}
+}
+
+func writeChangelogMarkdown(w io.Writer, cl *model.Changelog, cfg *config.Config) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", views.ChangelogTitle(cl))
+	fmt.Fprintf(&b, "%s\n", cl.Date.Format("2006-01-02"))
+
+	for _, s := range cl.Sections {
+		if len(s.Entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Title)
+		for _, e := range s.Entries {
+			fmt.Fprintf(&b, "- **%s/%s**", e.Category, e.Name)
+			if v := views.ChangelogValue(s.Kind, e); v != "" {
+				fmt.Fprintf(&b, " %s", v)
+			}
+			var links []string
+			for _, c := range e.Commits {
+				links = append(links, fmt.Sprintf("[%s](%s)", c.Hash[:7], views.CommitHref("", c, nil, cfg)))
+			}
+			if len(links) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(links, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if views.ChangelogEmpty(cl) {
+		b.WriteString("\nNo port changes.\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -1,31 +1,190 @@
 package build
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
 
+	"portsMaster/pkg/ci"
+	"portsMaster/pkg/config"
 	"portsMaster/pkg/model"
+	"portsMaster/views"
 )
 
-func (e *Engine) GenerateCIData(ports []*model.Port) error {
-	ciData := make(map[string]*model.CIInfo)
+// RunCI builds every port with the configured command and writes the
+// results to a ci_status.json that source.LoadCIStatus reads. It returns
+// the path written.
+func (e *Engine) RunCI(ctx context.Context, progress func(key string, info *model.CIInfo)) (string, map[string]*model.CIInfo, error) {
+	run := e.cfg.CI.Run
+	if run.Command == "" {
+		return "", nil, fmt.Errorf("no build command configured in [ci.run]")
+	}
+	if e.cfg.Rev != "" {
+		return "", nil, fmt.Errorf("ci run builds the working tree and cannot be pinned with --rev")
+	}
+	var timeout time.Duration
+	if run.Timeout != "" {
+		d, err := time.ParseDuration(run.Timeout)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid ci.run.timeout: %w", err)
+		}
+		timeout = d
+	}
+
+	output, logDir := ciRunPaths(e.cfg)
+	artifactDir := run.ArtifactDir
+	if artifactDir == "" {
+		artifactDir = filepath.Join(e.cfg.CacheDir, "artifacts")
+	}
+	workers := run.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	host, _ := os.Hostname()
+
+	_, ports, err := e.scanner.Scan(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r := &ci.Runner{
+		Command:     run.Command,
+		Workers:     workers,
+		Timeout:     timeout,
+		PortsRoot:   e.reg.PortsRoot(),
+		LogDir:      logDir,
+		ArtifactDir: artifactDir,
+		Builder:     fmt.Sprintf("%s (%s/%s)", host, runtime.GOOS, runtime.GOARCH),
+		LogPath:     relativeTo(filepath.Dir(output)),
+		Progress:    progress,
+	}
+	results := r.Run(ctx, ports)
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+	return output, results, writeCIStatus(output, results)
+}
+
+// ApplyCI stores results pushed for ports of the tree and re-renders the
+// pages showing them: the pages of the ports and their categories, the
+// stats page and the CI history. It returns the ports updated and those
+// unknown to the latest build, whose results are dropped. Until a build
+// completes, results are only stored for the next build to pick up.
+func (e *Engine) ApplyCI(ctx context.Context, results map[string]*model.CIInfo) (updated, unknown []string, err error) {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+
+	if e.cfg.Rev != "" {
+		return nil, nil, fmt.Errorf("a build pinned with --rev does not show CI results")
+	}
+	last := e.last
+	if last == nil {
+		return nil, nil, NewCollector(e.cfg, e.reg, e.scanner).inbox().Add(results)
+	}
+	col, db := last.col, last.db
+
+	byKey := make(map[string]*model.Port, len(db.Ports))
+	for _, p := range db.Ports {
+		byKey[p.Category+"/"+p.Name] = p
+	}
+	accepted := make(map[string]*model.CIInfo, len(results))
+	for key, info := range results {
+		if _, ok := byKey[key]; ok {
+			accepted[key] = info
+			updated = append(updated, key)
+		} else {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(updated)
+	sort.Strings(unknown)
+	if len(updated) == 0 {
+		return nil, unknown, nil
+	}
+	if err := col.inbox().Add(accepted); err != nil {
+		return nil, nil, err
+	}
 
+	var failures *failureScanner
+	if e.cfg.Logs.Enabled {
+		if failures, err = col.newFailureScanner(); err != nil {
+			return nil, nil, err
+		}
+	}
+	ports := make([]*model.Port, len(updated))
+	for i, key := range updated {
+		ports[i] = byKey[key]
+		info := *accepted[key]
+		col.applyCI(ctx, ports[i], &info, failures)
+	}
+	if failures != nil {
+		failures.save()
+	}
+	if e.cfg.CI.History {
+		col.attachCIHistory(ports, accepted)
+	}
+
+	data := col.PrepareSiteData(db)
+	dataHash := e.computeDataHash(data)
+	cats := make(map[string]bool)
 	for _, p := range ports {
-		path := filepath.Join(e.cfg.OutDir, "ports", p.Category, p.Name, "index.html")
-		status := "success"
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			status = "failed"
-		} else if p.IsBroken {
-			status = "broken"
+		path := fmt.Sprintf("ports/%s/%s/index.html", p.Category, p.Name)
+		e.render(path, views.PortDetail(data, p, e.cfg, path), e.computePortHash(p, last.globalHash, dataHash, data.SimplePortMap))
+		cats[p.Category] = true
+	}
+	for _, c := range db.Categories {
+		if cats[c.Name] {
+			path := fmt.Sprintf("categories/%s/index.html", c.Name)
+			e.render(path, views.CategoryDetail(data, c, e.cfg, path), e.computeCategoryHash(c, last.globalHash, dataHash))
 		}
+	}
+	e.renderCIPages(data, last.globalHash, dataHash)
+	e.exportJSON("ports.json", e.buildSearchIndex(db.Ports), last.globalHash)
+	return updated, unknown, e.manifest.Save(manifestPath(e.cfg))
+}
+
+// ciRunPaths returns the status file ci run writes and the directory its
+// logs go to.
+func ciRunPaths(cfg *config.Config) (output, logDir string) {
+	output = cfg.Output
+	if output == "" {
+		output = cfg.CI.Run.Output
+	}
+	if output == "" {
+		output = filepath.Join(cfg.OutDir, "ci_status.json")
+	}
+	logDir = cfg.CI.Run.LogDir
+	if logDir == "" {
+		logDir = filepath.Join(filepath.Dir(output), "logs")
+	}
+	return output, logDir
+}
 
-		ciData[p.Category+"/"+p.Name] = &model.CIInfo{
-			Status: status,
+// relativeTo reports log paths relative to the status file when they live
+// below it, the layout log_root expects, and as absolute paths otherwise.
+func relativeTo(base string) func(string) string {
+	return func(path string) string {
+		if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
 		}
+		return path
 	}
+}
 
-	f, err := os.Create(filepath.Join(e.cfg.OutDir, "ci_status.json"))
+func writeCIStatus(path string, results map[string]*model.CIInfo) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
@@ -33,5 +192,5 @@ func (e *Engine) GenerateCIData(ports []*model.Port) error {
 
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	return enc.Encode(ciData)
+	return enc.Encode(results)
 }
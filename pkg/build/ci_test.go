@@ -0,0 +1,55 @@
+package build
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"portsMaster/pkg/ci"
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+)
+
+func TestWriteCIStatusRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	r := &ci.Runner{
+		Command:     `echo artifact > "$ARTIFACT_DIR/out"; test "$PORT_NAME" != zlib`,
+		Workers:     2,
+		PortsRoot:   filepath.Join(dir, "ports"),
+		LogDir:      filepath.Join(dir, "logs"),
+		ArtifactDir: filepath.Join(dir, "artifacts"),
+		Builder:     "test",
+		LogPath:     func(path string) string { return "/logs/" + filepath.Base(path) },
+	}
+	var ports []*model.Port
+	for _, key := range [][2]string{{"core", "musl"}, {"core", "zlib"}, {"net", "curl"}} {
+		p := &model.Port{Category: key[0], Name: key[1], Version: "1.0", Release: "1"}
+		if key[1] == "curl" {
+			p.Deps = []model.Dependency{{Name: "zlib", Type: model.DepLink}}
+		}
+		if err := os.MkdirAll(filepath.Join(r.PortsRoot, key[0], key[1]), 0755); err != nil {
+			t.Fatal(err)
+		}
+		ports = append(ports, p)
+	}
+	results := r.Run(context.Background(), ports)
+
+	path := filepath.Join(dir, "out", "ci_status.json")
+	if err := writeCIStatus(path, results); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := source.LoadCIStatus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, results) {
+		t.Errorf("loaded %+v, want %+v", loaded, results)
+	}
+	for key, want := range map[string]string{"core/musl": "success", "core/zlib": "failed", "net/curl": "dependency-failed"} {
+		if info := loaded[key]; info == nil || info.Status != want {
+			t.Errorf("%s: got %+v, want status %q", key, info, want)
+		}
+	}
+}
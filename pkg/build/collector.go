@@ -1,15 +1,24 @@
 package build
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"portsMaster/pkg/ci"
 	"portsMaster/pkg/config"
+	"portsMaster/pkg/convention"
 	"portsMaster/pkg/model"
+	"portsMaster/pkg/pkgindex"
+	"portsMaster/pkg/port"
 	"portsMaster/pkg/registry"
 	"portsMaster/pkg/source"
+	"portsMaster/pkg/util"
 )
 
 // Collector gathers data from various sources to build the site database.
@@ -17,6 +26,9 @@ type Collector struct {
 	cfg     *config.Config
 	reg     *registry.Registry
 	scanner model.Scanner
+
+	mailmapOnce sync.Once
+	mailmap     *source.Mailmap
 }
 
 // NewCollector creates a new data collector.
@@ -40,44 +52,52 @@ func (c *Collector) Stream(ctx context.Context, portChan chan<- *model.Port, met
 		GeneratedAt: time.Now(),
 	}
 
-	if c.reg.PkgsRoot() != "" {
-		source.ScanPackages(c.reg, ports)
-	}
+	// A build pinned to a revision reproduces the tree as it was; packages
+	// and CI results describe the present and are left out.
+	pinned := c.cfg.Rev != ""
 
-	ciData := make(map[string]*model.CIInfo)
-	ciPath := c.cfg.CIStatus
-	if ciPath == "" {
-		ciPath = c.cfg.Metadata.CIStatus
+	if root := c.reg.PkgsRoot(); config.IsRemote(root) && !pinned {
+		hc := source.NewHTTPCache(filepath.Join(c.cfg.CacheDir, "remote_packages"), nil)
+		if err := source.ScanRemotePackages(ctx, hc, root, c.cfg.Packages.RemoteIndex, ports); err != nil {
+			fmt.Printf("warning: could not read remote packages: %v\n", err)
+		}
+	} else if root != "" && !pinned {
+		source.ScanPackages(c.reg, ports)
+		if c.cfg.Packages.Index {
+			c.indexPackages(ports)
+			pkgindex.CheckLinks(ports)
+			c.recordHardening(db, pkgindex.CheckHardening(ports))
+		}
 	}
 
-	if ciPath != "" {
-		if data, err := source.LoadCIStatus(ciPath); err == nil {
-			ciData = data
+	var ciData map[string]*model.CIInfo
+	if !pinned {
+		data, err := c.loadCI(ctx)
+		if err != nil {
+			return err
+		}
+		ciData = data
+		if c.cfg.CI.History {
+			c.attachCIHistory(ports, ciData)
 		}
 	}
 
 	if gp, err := source.NewGitProvider(c.reg.PortsRoot()); err == nil {
-		history, recent, stats, _ := gp.GetRepositoryDataCached(ports, c.cfg.CacheDir)
-		db.RecentCommits = recent
-		db.ContributorStats = stats
-
-		for _, p := range ports {
-			if commits, ok := history[p.Category+"/"+p.Name]; ok && len(commits) > 0 {
-				p.LastCommit = commits[0]
-				p.Commits = commits
-			}
+		gp.SetMailmap(c.loadMailmap())
+		gp.SetKeyring(c.loadKeyring())
+		c.attachHistory(db, gp.At(c.cfg.Rev))
+	}
+
+	var failures *failureScanner
+	if !pinned && c.cfg.Logs.Enabled {
+		if failures, err = c.newFailureScanner(); err != nil {
+			return err
 		}
 	}
 
 	for _, p := range ports {
-		if ci, ok := ciData[p.Category+"/"+p.Name]; ok {
-			p.CI = ci
-			// Prefix BuildLog with LogsPath if set.
-			// We check if BuildLog is already a remote URL or absolute path.
-			logsRoot := c.cfg.Metadata.LogsPath
-			if logsRoot != "" && p.CI.BuildLog != "" && !config.IsRemote(p.CI.BuildLog) && !strings.HasPrefix(p.CI.BuildLog, "/") {
-				p.CI.BuildLog = strings.TrimRight(logsRoot, "/") + "/" + p.CI.BuildLog
-			}
+		if info, ok := ciData[p.Category+"/"+p.Name]; ok {
+			c.applyCI(ctx, p, info, failures)
 		}
 		select {
 		case <-ctx.Done():
@@ -85,11 +105,268 @@ func (c *Collector) Stream(ctx context.Context, portChan chan<- *model.Port, met
 		case portChan <- p:
 		}
 	}
+	if failures != nil {
+		failures.prune()
+		failures.save()
+	}
 
 	metaChan <- db
 	return nil
 }
 
+// indexPackages lists the contents of every package archive.
+func (c *Collector) indexPackages(ports []*model.Port) {
+	ix := pkgindex.New(c.cfg.CacheDir)
+	for _, p := range ports {
+		for i := range p.Packages {
+			if err := ix.Index(&p.Packages[i]); err != nil {
+				fmt.Printf("warning: could not index package %s: %v\n", p.Packages[i].Filename, err)
+			}
+		}
+	}
+}
+
+// recordHardening adds the tree-wide hardening summary to its trend and
+// attaches the trend to the database.
+func (c *Collector) recordHardening(db *model.Database, total model.HardeningSummary) {
+	trend := pkgindex.OpenTrend(filepath.Join(c.cfg.CacheDir, "hardening.jsonl"))
+	if err := trend.Record(total, db.GeneratedAt); err != nil {
+		fmt.Printf("warning: could not record hardening trend: %v\n", err)
+	}
+	snaps, err := trend.Load()
+	if err != nil {
+		fmt.Printf("warning: could not load hardening trend: %v\n", err)
+	}
+	db.HardeningTrend = snaps
+}
+
+// applyCI sets the CI result of a port and, for a failed build, the
+// failing step found in its log.
+func (c *Collector) applyCI(ctx context.Context, p *model.Port, info *model.CIInfo, failures *failureScanner) {
+	p.CI = info
+	p.Failure = nil
+	// Prefix BuildLog with LogsPath if set.
+	// We check if BuildLog is already a remote URL or absolute path.
+	logsRoot := c.cfg.Metadata.LogsPath
+	if logsRoot != "" && p.CI.BuildLog != "" && !config.IsRemote(p.CI.BuildLog) && !strings.HasPrefix(p.CI.BuildLog, "/") {
+		p.CI.BuildLog = strings.TrimRight(logsRoot, "/") + "/" + p.CI.BuildLog
+	}
+	if failures != nil && c.cfg.CI.Vocabulary.Failing(p.CI.Status) {
+		p.Failure = failures.scan(ctx, p.Category+"/"+p.Name, p.CI)
+	}
+}
+
+// loadCI merges the results of every configured CI source with those
+// pushed to the API.
+func (c *Collector) loadCI(ctx context.Context) (map[string]*model.CIInfo, error) {
+	providers, err := ci.Providers(c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ci.LoadAll(ctx, providers, c.cfg.CI.Merge)
+	if err != nil {
+		return nil, err
+	}
+	received, err := c.inbox().Load()
+	if err != nil {
+		fmt.Printf("warning: could not read received CI results: %v\n", err)
+	}
+	ci.Overlay(data, received)
+	return data, nil
+}
+
+func (c *Collector) inbox() *ci.Inbox {
+	return ci.OpenInbox(filepath.Join(c.cfg.CacheDir, "ci_received.json"))
+}
+
+// ciHistoryDays is how far back port pages and daily stats look into the
+// CI history; ciTimelineLen is the minimum number of records kept anyway.
+const (
+	ciHistoryDays = 60
+	ciTimelineLen = 30
+)
+
+// attachCIHistory records the snapshot in the CI history and annotates
+// every port with its recent records, failure streak and flakiness.
+func (c *Collector) attachCIHistory(ports []*model.Port, snapshot map[string]*model.CIInfo) {
+	// Only results of scanned ports are kept; the rest would grow the
+	// history forever.
+	known := make(map[string]*model.CIInfo, len(snapshot))
+	for _, p := range ports {
+		key := p.Category + "/" + p.Name
+		if info, ok := snapshot[key]; ok {
+			known[key] = info
+		}
+	}
+	hist := ci.OpenHistory(filepath.Join(c.cfg.CacheDir, "ci_history"))
+	now := time.Now()
+	if err := hist.Record(known, now); err != nil {
+		fmt.Printf("warning: could not record CI history: %v\n", err)
+	}
+
+	r := c.cfg.CI.Regressions
+	thresholds := ci.Thresholds{Window: r.Window, Duration: r.Duration, Size: r.Size, MinDuration: r.MinDuration, MinSize: r.MinSize}
+	cutoff := now.AddDate(0, 0, -ciHistoryDays).Unix()
+	for _, p := range ports {
+		records, err := hist.Load(p.Category + "/" + p.Name)
+		if err != nil {
+			fmt.Printf("warning: could not read CI history of %s/%s: %v\n", p.Category, p.Name, err)
+			continue
+		}
+		p.BrokenSince = ci.BrokenSince(records, c.cfg.CI.Vocabulary)
+		p.CIFlips = ci.Flips(records, c.cfg.CI.FlakyWindow, c.cfg.CI.Vocabulary)
+		p.Flaky = p.CIFlips >= c.cfg.CI.FlakyFlips
+		p.Regressions = nil
+		if c.cfg.CI.Regressions.Enabled {
+			p.Regressions = ci.Regressions(records, thresholds, c.cfg.CI.Vocabulary)
+		}
+
+		start := len(records) - ciTimelineLen
+		for start > 0 && records[start-1].Time() >= cutoff {
+			start--
+		}
+		p.CIHistory = records[max(start, 0):]
+	}
+}
+
+// CollectRef scans the ports tree as it is at a git ref, reading straight
+// from git objects, and attaches the history reachable from that ref.
+// Binary packages and CI results only describe the working tree and are
+// not applied.
+func (c *Collector) CollectRef(ctx context.Context, ref string) (*model.Database, error) {
+	gp, err := source.NewGitProvider(c.reg.PortsRoot())
+	if err != nil {
+		return nil, err
+	}
+	gp.SetMailmap(c.loadMailmap())
+	gp.SetKeyring(c.loadKeyring())
+
+	db, err := c.scanRef(ctx, gp, ref)
+	if err != nil {
+		return nil, err
+	}
+	c.attachHistory(db, gp.At(ref))
+	return db, nil
+}
+
+// scanRef parses the ports tree at ref without attaching any history.
+func (c *Collector) scanRef(ctx context.Context, gp *source.GitProvider, ref string) (*model.Database, error) {
+	fsys, err := gp.TreeFS(ref)
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := port.NewScannerFS(c.cfg, c.reg, fsys)
+	if err != nil {
+		return nil, err
+	}
+	cats, ports, err := scanner.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Database{
+		Categories:  cats,
+		Ports:       ports,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+func (c *Collector) attachHistory(db *model.Database, gp *source.GitProvider) {
+	repo, err := gp.GetRepositoryDataCached(db.Ports, c.cfg.CacheDir)
+	if err != nil {
+		return
+	}
+	db.RecentCommits = repo.RecentCommits
+	db.ContributorStats = repo.ContributorStats
+	db.RemovedPorts = c.resolveRemovedPorts(gp, repo.RemovedPorts)
+	db.FileCommits = repo.FileCommits
+
+	for _, p := range db.Ports {
+		if commits, ok := repo.PortCommits[p.Category+"/"+p.Name]; ok && len(commits) > 0 {
+			p.LastCommit = commits[0]
+			p.Commits = commits
+		}
+	}
+
+	if checker, err := convention.New(c.cfg); err == nil {
+		forEachCommit(db.RecentCommits, db.Ports, db.RemovedPorts, checker.Classify)
+	} else {
+		fmt.Printf("warning: %v\n", err)
+	}
+}
+
+// loadMailmap combines the ports tree's .mailmap with the alias table from
+// the configuration, the latter taking precedence. It is read once and
+// shared by every use of the collector.
+func (c *Collector) loadMailmap() *source.Mailmap {
+	c.mailmapOnce.Do(func() { c.mailmap = c.readMailmap() })
+	return c.mailmap
+}
+
+func (c *Collector) readMailmap() *source.Mailmap {
+	path := c.cfg.Mailmap
+	if path == "" {
+		path = filepath.Join(c.reg.PortsRoot(), ".mailmap")
+	}
+	mm, err := source.LoadMailmap(path)
+	if err != nil {
+		fmt.Printf("warning: could not read mailmap %s: %v\n", path, err)
+		mm = source.NewMailmap()
+	}
+	for _, a := range c.cfg.Aliases {
+		for _, e := range a.Emails {
+			mm.AddAlias(a.Name, a.Email, "", e)
+		}
+		for _, n := range a.Names {
+			mm.AddAlias(a.Name, a.Email, n, "")
+		}
+		if a.Email != "" {
+			mm.AddAlias(a.Name, a.Email, "", a.Email)
+		}
+	}
+	return mm
+}
+
+// loadKeyring reads the keys commit signatures are checked against, or
+// returns nil when verification is not configured.
+func (c *Collector) loadKeyring() *source.Keyring {
+	if c.cfg.Signatures.Keyring == "" {
+		return nil
+	}
+	k, err := source.LoadKeyring(c.cfg.Signatures.Keyring)
+	if err != nil {
+		fmt.Printf("warning: could not read keyring %s: %v\n", c.cfg.Signatures.Keyring, err)
+		return nil
+	}
+	return k
+}
+
+// resolveRemovedPorts fills in the last known metadata of removed ports from
+// the revision just before their removal. Directories that never held a
+// metadata file were not ports and are dropped.
+func (c *Collector) resolveRemovedPorts(gp *source.GitProvider, removed []*model.RemovedPort) []*model.RemovedPort {
+	parser, err := port.NewParser(c.cfg, c.reg)
+	if err != nil {
+		return nil
+	}
+	mp, ok := parser.(model.MetadataParser)
+	if !ok {
+		return nil
+	}
+
+	var out []*model.RemovedPort
+	for _, r := range removed {
+		content, err := gp.ReadFile(r.LastHash, r.Category+"/"+r.Name+"/"+mp.MetadataFile())
+		if err != nil {
+			continue
+		}
+		if p, err := mp.ParseMetadata(r.Category, r.Name, bytes.NewReader(content)); err == nil {
+			r.LastKnown = p
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
 // PrepareSiteData processes the database into a format suitable for view rendering.
 func (c *Collector) PrepareSiteData(db *model.Database) *model.SiteData {
 	data := &model.SiteData{
@@ -98,11 +375,14 @@ func (c *Collector) PrepareSiteData(db *model.Database) *model.SiteData {
 		PortMap:          make(map[string]*model.Port),
 		SimplePortMap:    make(map[string]*model.Port),
 		RecentCommits:    db.RecentCommits,
+		RemovedPorts:     db.RemovedPorts,
 		TotalPorts:       len(db.Ports),
 		LastUpdate:       db.GeneratedAt,
 		ContributorStats: db.ContributorStats,
 		LicenseStats:     make(map[string]int),
+		BuildStats:       model.BuildStats{ByStatus: make(map[string]int)},
 	}
+	vocab := c.cfg.CI.Vocabulary
 
 	activity := make(map[string]int)
 	updates := make(map[string]int)
@@ -110,6 +390,9 @@ func (c *Collector) PrepareSiteData(db *model.Database) *model.SiteData {
 
 	for _, c := range db.RecentCommits {
 		activity[c.Date.Format("2006-01-02")]++
+		if c.Signature == model.SignatureUnsigned {
+			data.UnsignedRecent++
+		}
 	}
 
 	weekAgo := time.Now().AddDate(0, 0, -7)
@@ -127,7 +410,7 @@ func (c *Collector) PrepareSiteData(db *model.Database) *model.SiteData {
 
 		data.TotalRecipeLines += p.RecipeLines
 
-		if p.IsBroken || (p.CI != nil && p.CI.Status == "failed") {
+		if p.IsBroken || (p.CI != nil && vocab.Failing(p.CI.Status)) {
 			data.BrokenCount++
 		}
 		if p.IsUnmaintained {
@@ -155,31 +438,128 @@ func (c *Collector) PrepareSiteData(db *model.Database) *model.SiteData {
 		}
 		if p.CI != nil {
 			data.BuildStats.Total++
-			if p.CI.Status == "success" {
+			data.BuildStats.ByStatus[p.CI.Status]++
+			switch vocab.Lookup(p.CI.Status).Kind {
+			case config.CIKindSuccess:
 				data.BuildStats.Success++
 				data.BuildStats.TotalTime += p.CI.BuildDuration
-			} else {
+			case config.CIKindFailure:
 				data.BuildStats.Failed++
+			case config.CIKindPending:
+				data.BuildStats.Pending++
+			default:
+				data.BuildStats.Neutral++
 			}
-			if p.CI.BuildStarted > 0 {
+			if p.CI.BuildStarted > 0 && len(p.CIHistory) == 0 {
 				buildDate := time.Unix(p.CI.BuildStarted, 0).Format("2006-01-02")
 				builds[buildDate]++
 			}
 		}
+		for _, r := range p.CIHistory {
+			builds[time.Unix(r.Time(), 0).Format("2006-01-02")]++
+		}
 	}
 
 	if data.BuildStats.Success > 0 {
 		data.BuildStats.AvgTime = data.BuildStats.TotalTime / int64(data.BuildStats.Success)
 	}
 
+	c.finalizeCommitPages(data)
+	c.finalizeConventions(data)
 	c.finalizeContributorStats(data)
 	c.finalizeRecipeStats(data)
 	c.finalizeSizeStats(data)
+	c.finalizeCIHistory(data)
+	c.finalizeFailureReasons(data)
+	c.finalizeHardening(data, db.HardeningTrend)
 	c.finalizeActivityStats(data, activity, updates, builds)
 
 	return data
 }
 
+// finalizeCIHistory lists broken, flaky and regressed ports and the most
+// recent CI status changes across the tree.
+func (c *Collector) finalizeCIHistory(data *model.SiteData) {
+	const transitionLimit = 100
+	for _, p := range data.Ports {
+		if p.BrokenSince > 0 {
+			data.CIBroken = append(data.CIBroken, p)
+		}
+		if p.Flaky {
+			data.CIFlaky = append(data.CIFlaky, p)
+		}
+		if len(p.Regressions) > 0 {
+			data.CIRegressed = append(data.CIRegressed, p)
+		}
+		for i := 1; i < len(p.CIHistory); i++ {
+			prev, cur := p.CIHistory[i-1], p.CIHistory[i]
+			if prev.Status != cur.Status {
+				data.CITransitions = append(data.CITransitions, model.CITransition{Port: p, From: prev.Status, To: cur.Status, At: cur.Time()})
+			}
+		}
+	}
+	sort.Slice(data.CIBroken, func(i, j int) bool {
+		return data.CIBroken[i].BrokenSince < data.CIBroken[j].BrokenSince
+	})
+	sort.Slice(data.CIFlaky, func(i, j int) bool {
+		return data.CIFlaky[i].CIFlips > data.CIFlaky[j].CIFlips
+	})
+	sort.Slice(data.CITransitions, func(i, j int) bool {
+		return data.CITransitions[i].At > data.CITransitions[j].At
+	})
+	sort.Slice(data.CIRegressed, func(i, j int) bool {
+		return worstRegression(data.CIRegressed[i]) > worstRegression(data.CIRegressed[j])
+	})
+	if len(data.CITransitions) > transitionLimit {
+		data.CITransitions = data.CITransitions[:transitionLimit]
+	}
+}
+
+// worstRegression is the largest growth among a port's regressions.
+func worstRegression(p *model.Port) float64 {
+	var worst float64
+	for _, r := range p.Regressions {
+		worst = max(worst, r.Change())
+	}
+	return worst
+}
+
+// finalizeFailureReasons ranks failure categories by the number of failed
+// ports they explain.
+func (c *Collector) finalizeFailureReasons(data *model.SiteData) {
+	counts := make(map[string]int)
+	for _, p := range data.Ports {
+		if p.Failure != nil {
+			counts[p.Failure.Category]++
+		}
+	}
+	for cat, n := range counts {
+		data.FailureReasons = append(data.FailureReasons, model.FailureReason{Category: cat, Count: n})
+	}
+	sort.Slice(data.FailureReasons, func(i, j int) bool {
+		a, b := data.FailureReasons[i], data.FailureReasons[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Category < b.Category
+	})
+}
+
+// finalizeHardening totals the hardening of every port and ranks the ports
+// least hardened first.
+func (c *Collector) finalizeHardening(data *model.SiteData, trend []model.HardeningSnapshot) {
+	data.HardeningTrend = trend
+	for _, p := range data.Ports {
+		if p.Hardening != nil {
+			data.Hardening.Merge(*p.Hardening)
+			data.HardeningPorts = append(data.HardeningPorts, p)
+		}
+	}
+	sort.SliceStable(data.HardeningPorts, func(i, j int) bool {
+		return data.HardeningPorts[i].Hardening.Coverage() < data.HardeningPorts[j].Hardening.Coverage()
+	})
+}
+
 func (c *Collector) finalizeSizeStats(data *model.SiteData) {
 	for _, p := range data.Ports {
 		if p.CI != nil && p.CI.Size > 0 {
@@ -194,6 +574,61 @@ func (c *Collector) finalizeSizeStats(data *model.SiteData) {
 	}
 }
 
+// finalizeCommitPages selects the commits that get a static page, honoring
+// the configured retention window.
+func (c *Collector) finalizeCommitPages(data *model.SiteData) {
+	data.CommitPages = make(map[string]*model.Commit)
+	if !c.cfg.CommitPages.Enabled {
+		return
+	}
+
+	cutoff := c.cfg.CommitPageCutoff()
+	forEachCommit(data.RecentCommits, data.Ports, data.RemovedPorts, func(commit *model.Commit) {
+		if commit.Date.After(cutoff) {
+			data.CommitPages[commit.Hash] = commit
+		}
+	})
+}
+
+// finalizeConventions counts commits per kind and collects, newest first,
+// those breaking the commit convention.
+func (c *Collector) finalizeConventions(data *model.SiteData) {
+	data.CommitTypeCounts = make(map[string]int)
+	seen := make(map[string]bool)
+	forEachCommit(data.RecentCommits, data.Ports, data.RemovedPorts, func(commit *model.Commit) {
+		if seen[commit.Hash] || commit.Type == "" {
+			return
+		}
+		seen[commit.Hash] = true
+		data.CommitTypeCounts[commit.Type]++
+		if commit.Violation != "" {
+			data.Violations = append(data.Violations, commit)
+		}
+	})
+	sort.Slice(data.Violations, func(i, j int) bool {
+		return data.Violations[i].Date.After(data.Violations[j].Date)
+	})
+}
+
+// forEachCommit calls fn for every commit referenced by the recent list, the
+// port histories and the removed ports. Commits loaded from the history cache
+// are not shared between these lists, so the same hash may be seen twice.
+func forEachCommit(recent []*model.Commit, ports []*model.Port, removed []*model.RemovedPort, fn func(*model.Commit)) {
+	for _, commit := range recent {
+		fn(commit)
+	}
+	for _, p := range ports {
+		for _, commit := range p.Commits {
+			fn(commit)
+		}
+	}
+	for _, r := range removed {
+		if r.Commit != nil {
+			fn(r.Commit)
+		}
+	}
+}
+
 func (c *Collector) finalizeContributorStats(data *model.SiteData) {
 	for _, v := range data.ContributorStats {
 		data.AllAuthors = append(data.AllAuthors, v.Name)
@@ -202,8 +637,115 @@ func (c *Collector) finalizeContributorStats(data *model.SiteData) {
 	}
 	sort.Strings(data.AllAuthors)
 	sort.Slice(data.TopContributors, func(i, j int) bool {
-		return data.TopContributors[i].Count > data.TopContributors[j].Count
+		if data.TopContributors[i].Count != data.TopContributors[j].Count {
+			return data.TopContributors[i].Count > data.TopContributors[j].Count
+		}
+		return data.TopContributors[i].Email < data.TopContributors[j].Email
 	})
+
+	mm := c.loadMailmap()
+	maintainers := make(map[*model.Port]model.Person)
+	for _, p := range data.Ports {
+		if p.Maintainer != "" {
+			maintainers[p] = source.ParsePerson(p.Maintainer, mm)
+		}
+	}
+
+	// Slugs are handed out in email order rather than by rank, so a
+	// contributor's URL does not change when the ranking does.
+	byEmail := append([]*model.Contributor{}, data.TopContributors...)
+	sort.Slice(byEmail, func(i, j int) bool {
+		if byEmail[i].Email != byEmail[j].Email {
+			return byEmail[i].Email < byEmail[j].Email
+		}
+		return byEmail[i].Name < byEmail[j].Name
+	})
+	taken := make(map[string]bool)
+	for _, v := range byEmail {
+		base := util.Slugify(v.Name)
+		if base == "" {
+			base = util.Slugify(v.Email)
+		}
+		slug := base
+		for i := 2; taken[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[slug] = true
+		v.Slug = slug
+	}
+	for _, v := range data.TopContributors {
+		data.Profiles = append(data.Profiles, c.buildProfile(data, v, maintainers))
+	}
+}
+
+// maintains reports whether the canonical maintainer identity m is the
+// contributor v: by email, or by name when the maintainer has no email.
+func maintains(v *model.Contributor, m model.Person) bool {
+	if m.Email != "" {
+		return strings.EqualFold(m.Email, v.Email)
+	}
+	if m.Name == "" {
+		return false
+	}
+	for _, n := range append([]string{v.Name}, v.OtherNames...) {
+		if strings.EqualFold(n, m.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildProfile assembles the contributor page data: maintained ports, most
+// touched ports, monthly commit counts and a one-year daily heatmap.
+func (c *Collector) buildProfile(data *model.SiteData, v *model.Contributor, maintainers map[*model.Port]model.Person) *model.ContributorProfile {
+	prof := &model.ContributorProfile{Contributor: v, MaxMonthly: 1, MaxDaily: 1}
+
+	for _, p := range data.Ports {
+		if m, ok := maintainers[p]; ok && maintains(v, m) {
+			prof.Maintained = append(prof.Maintained, p)
+		}
+	}
+
+	for key, n := range v.Ports {
+		prof.TopPorts = append(prof.TopPorts, model.PortCount{Key: key, Count: n})
+	}
+	sort.Slice(prof.TopPorts, func(i, j int) bool {
+		if prof.TopPorts[i].Count != prof.TopPorts[j].Count {
+			return prof.TopPorts[i].Count > prof.TopPorts[j].Count
+		}
+		return prof.TopPorts[i].Key < prof.TopPorts[j].Key
+	})
+	if len(prof.TopPorts) > 10 {
+		prof.TopPorts = prof.TopPorts[:10]
+	}
+
+	monthly := make(map[string]int)
+	for day, n := range v.Daily {
+		monthly[day[:7]] += n
+	}
+	if !v.FirstCommit.IsZero() {
+		first := time.Date(v.FirstCommit.Year(), v.FirstCommit.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for m := first; !m.After(time.Now()); m = m.AddDate(0, 1, 0) {
+			key := m.Format("2006-01")
+			prof.Monthly = append(prof.Monthly, model.DailyStat{Date: key, Count: monthly[key]})
+			if monthly[key] > prof.MaxMonthly {
+				prof.MaxMonthly = monthly[key]
+			}
+		}
+	}
+
+	// Start on a Sunday so the heatmap columns line up as weeks.
+	start := time.Now().AddDate(-1, 0, 0)
+	start = start.AddDate(0, 0, -int(start.Weekday()))
+	for d := start; !d.After(time.Now()); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		prof.Heatmap = append(prof.Heatmap, model.DailyStat{Date: key, Count: v.Daily[key]})
+		if v.Daily[key] > prof.MaxDaily {
+			prof.MaxDaily = v.Daily[key]
+		}
+	}
+
+	return prof
 }
 
 func (c *Collector) finalizeRecipeStats(data *model.SiteData) {
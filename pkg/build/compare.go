@@ -0,0 +1,62 @@
+package build
+
+import (
+	"sort"
+
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/util"
+)
+
+// compareDatabases lists the ports added, removed and changed in version
+// when going from one parsed tree to another.
+func compareDatabases(fromName, toName string, from, to *model.Database) *model.BranchComparison {
+	cmp := &model.BranchComparison{From: fromName, To: toName}
+
+	old := make(map[string]*model.Port, len(from.Ports))
+	for _, p := range from.Ports {
+		old[p.Category+"/"+p.Name] = p
+	}
+
+	seen := make(map[string]bool, len(to.Ports))
+	for _, p := range to.Ports {
+		key := p.Category + "/" + p.Name
+		seen[key] = true
+		op, ok := old[key]
+		if !ok {
+			cmp.Added = append(cmp.Added, p)
+			continue
+		}
+		if d := util.CompareVersions(versionOf(p), versionOf(op)); d != 0 {
+			cmp.Changed = append(cmp.Changed, model.VersionChange{From: op, To: p, Direction: d})
+		} else {
+			cmp.Unchanged++
+		}
+	}
+	for key, p := range old {
+		if !seen[key] {
+			cmp.Removed = append(cmp.Removed, p)
+		}
+	}
+
+	sortPorts(cmp.Added)
+	sortPorts(cmp.Removed)
+	sort.Slice(cmp.Changed, func(i, j int) bool {
+		a, b := cmp.Changed[i].To, cmp.Changed[j].To
+		return a.Category+"/"+a.Name < b.Category+"/"+b.Name
+	})
+	return cmp
+}
+
+// versionOf returns the full version including the release, if any.
+func versionOf(p *model.Port) string {
+	if p.Release == "" {
+		return p.Version
+	}
+	return p.Version + "-" + p.Release
+}
+
+func sortPorts(ports []*model.Port) {
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Category+"/"+ports[i].Name < ports[j].Category+"/"+ports[j].Name
+	})
+}
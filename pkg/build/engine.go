@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -19,6 +21,8 @@ import (
 	"portsMaster/pkg/model"
 	"portsMaster/pkg/port"
 	"portsMaster/pkg/registry"
+	"portsMaster/pkg/source"
+	"portsMaster/pkg/util"
 	"portsMaster/views"
 
 	"github.com/a-h/templ"
@@ -30,15 +34,33 @@ type Engine struct {
 	cfg      *config.Config
 	reg      *registry.Registry
 	scanner  model.Scanner
+	fsys     fs.FS
 	manifest *cache.Manifest
 	touched  map[string]bool
 	mu       sync.Mutex
 	Ready    chan struct{}
+
+	// last is the outcome of the latest Run, which ApplyCI updates.
+	last *lastRun
+}
+
+type lastRun struct {
+	col        *Collector
+	db         *model.Database
+	globalHash string
 }
 
+// buildMu serializes builds and CI updates, which share the output
+// directory and the manifest.
+var buildMu sync.Mutex
+
 func New(cfg *config.Config) (*Engine, error) {
 	reg := registry.New(cfg.PortsPath, cfg.Metadata.PkgsPath, cfg.Metadata.LogsPath, cfg.OutDir, cfg.AssetsDir)
-	scanner, err := port.NewScanner(cfg, reg)
+	fsys, err := portsFS(cfg, reg)
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := port.NewScannerFS(cfg, reg, fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -47,13 +69,39 @@ func New(cfg *config.Config) (*Engine, error) {
 		cfg:      cfg,
 		reg:      reg,
 		scanner:  scanner,
-		manifest: cache.LoadManifest(filepath.Join(cfg.CacheDir, "manifest.json")),
+		fsys:     fsys,
+		manifest: cache.LoadManifest(manifestPath(cfg)),
 		touched:  make(map[string]bool),
 		Ready:    make(chan struct{}),
 	}, nil
 }
 
+// manifestPath gives pinned builds, which write to out_dir/rev/<rev>, a
+// manifest of their own next to the regular build's.
+func manifestPath(cfg *config.Config) string {
+	if cfg.Rev == "" {
+		return filepath.Join(cfg.CacheDir, "manifest.json")
+	}
+	return filepath.Join(cfg.CacheDir, "manifest_"+util.Slugify(cfg.Rev)+".json")
+}
+
+// portsFS returns the working tree, or the git objects of cfg.Rev when the
+// build is pinned to a revision.
+func portsFS(cfg *config.Config, reg *registry.Registry) (fs.FS, error) {
+	if cfg.Rev == "" {
+		return os.DirFS(reg.PortsRoot()), nil
+	}
+	gp, err := source.NewGitProvider(reg.PortsRoot())
+	if err != nil {
+		return nil, fmt.Errorf("--rev requires a git ports tree: %w", err)
+	}
+	return gp.TreeFS(cfg.Rev)
+}
+
 func (e *Engine) Run(ctx context.Context) error {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+
 	col := NewCollector(e.cfg, e.reg, e.scanner)
 	portChan := make(chan *model.Port, 100)
 	metaChan := make(chan *model.Database, 1)
@@ -100,9 +148,18 @@ func (e *Engine) Run(ctx context.Context) error {
 	e.renderCorePages(siteData, db, globalHash, dataHash)
 	e.renderCategories(siteData, db, globalHash, dataHash)
 	e.renderPorts(siteData, db, globalHash, dataHash)
+	e.renderPortFiles(col, siteData, db, globalHash, dataHash)
+	e.renderPackageContents(siteData, db, globalHash, dataHash)
+	e.renderHardeningReport(siteData, globalHash, dataHash)
+	e.renderGraveyard(siteData, globalHash, dataHash)
+	e.renderCommitPages(col, siteData, globalHash)
+	e.renderContributors(siteData, globalHash, dataHash)
+	e.renderBranches(ctx, col, siteData, globalHash)
+	e.renderReleases(ctx, col, siteData, globalHash)
 
 	e.exportJSON("ports.json", e.buildSearchIndex(db.Ports), globalHash)
 	e.exportJSON("commits.json", db.RecentCommits, globalHash)
+	e.exportJSON("contributors.json", e.buildContributorIndex(siteData), globalHash)
 
 	select {
 	case e.Ready <- struct{}{}:
@@ -110,7 +167,8 @@ func (e *Engine) Run(ctx context.Context) error {
 	}
 
 	e.cleanup()
-	return e.manifest.Save(filepath.Join(e.cfg.CacheDir, "manifest.json"))
+	e.last = &lastRun{col: col, db: db, globalHash: globalHash}
+	return e.manifest.Save(manifestPath(e.cfg))
 }
 
 func (e *Engine) renderCorePages(data *model.SiteData, db *model.Database, globalHash, dataHash string) {
@@ -121,13 +179,57 @@ func (e *Engine) renderCorePages(data *model.SiteData, db *model.Database, globa
 		{"index.html", views.Home(data, e.getRecentUpdates(db.Ports), e.cfg, "index.html")},
 		{"categories/index.html", views.CategoryList(data, e.cfg, "categories/index.html")},
 		{"commits/index.html", views.Commits(data, e.cfg, "commits/index.html")},
-		{"stats/index.html", views.Stats(data, e.cfg, "stats/index.html")},
 		{"search/index.html", views.Search(data, e.cfg, "search/index.html")},
+		{"reports/conventions/index.html", views.ConventionReport(data, e.cfg, "reports/conventions/index.html")},
 	}
 
 	for _, p := range pages {
 		e.render(p.path, p.comp, cache.HashString(globalHash+dataHash+p.path))
 	}
+	e.renderCIPages(data, globalHash, dataHash)
+}
+
+// renderCIPages renders the pages summarizing CI results across the tree,
+// which change with any port's result.
+func (e *Engine) renderCIPages(data *model.SiteData, globalHash, dataHash string) {
+	stats := cache.NewHasher()
+	hist := cache.NewHasher()
+	for _, p := range data.Ports {
+		if p.CI != nil {
+			stats.Add(fmt.Sprintf("%s/%s-%s-%d", p.Category, p.Name, p.CI.Status, p.CI.BuildStarted))
+		}
+		if n := len(p.CIHistory); n > 0 {
+			hist.Add(fmt.Sprintf("%s/%s-%d-%d-%d", p.Category, p.Name, n, p.CIHistory[n-1].Seen, p.CIFlips))
+		}
+	}
+
+	stats.Add(fmt.Sprintf("%+v", data.Hardening))
+
+	path := "stats/index.html"
+	e.render(path, views.Stats(data, e.cfg, path), cache.HashString(globalHash+dataHash+path+stats.Sum()))
+	path = "ci/history/index.html"
+	e.render(path, views.CIHistory(data, e.cfg, path), cache.HashString(globalHash+dataHash+path+hist.Sum()))
+	if e.cfg.CI.Regressions.Enabled {
+		path = "reports/regressions/index.html"
+		e.render(path, views.RegressionReport(data, e.cfg, path), cache.HashString(globalHash+dataHash+path+hist.Sum()))
+	}
+}
+
+// renderHardeningReport renders the tree-wide hardening report.
+func (e *Engine) renderHardeningReport(data *model.SiteData, globalHash, dataHash string) {
+	if data.Hardening.Binaries == 0 && len(data.HardeningTrend) == 0 {
+		return
+	}
+	h := cache.NewHasher()
+	h.Add(globalHash + dataHash)
+	for _, p := range data.HardeningPorts {
+		h.Add(fmt.Sprintf("%s/%s-%+v", p.Category, p.Name, *p.Hardening))
+	}
+	for _, s := range data.HardeningTrend {
+		h.Add(fmt.Sprintf("%+v", s))
+	}
+	path := "reports/hardening/index.html"
+	e.render(path, views.HardeningReport(data, e.cfg, path), h.Sum())
 }
 
 func (e *Engine) renderCategories(data *model.SiteData, db *model.Database, globalHash, dataHash string) {
@@ -164,6 +266,219 @@ func (e *Engine) renderPorts(data *model.SiteData, db *model.Database, globalHas
 	wg.Wait()
 }
 
+func (e *Engine) renderGraveyard(data *model.SiteData, globalHash, dataHash string) {
+	h := cache.NewHasher()
+	h.Add(globalHash + dataHash)
+	for _, r := range data.RemovedPorts {
+		path := fmt.Sprintf("ports/%s/%s/index.html", r.Category, r.Name)
+		rh := cache.HashString(globalHash + dataHash + path + r.Commit.Hash)
+		e.render(path, views.Tombstone(data, r, e.cfg, path), rh)
+		h.Add(rh)
+	}
+	e.render("graveyard/index.html", views.Graveyard(data, e.cfg, "graveyard/index.html"), h.Sum())
+}
+
+// renderBranches renders the configured extra refs under /branches/<name>/
+// and the comparison pages between them.
+func (e *Engine) renderBranches(ctx context.Context, col *Collector, main *model.SiteData, globalHash string) {
+	if len(e.cfg.Branches) == 0 {
+		return
+	}
+
+	dbs := make(map[string]*model.Database)
+	for _, b := range e.cfg.Branches {
+		db, err := col.CollectRef(ctx, b.Ref)
+		if err != nil {
+			fmt.Printf("warning: could not scan branch %s (%s): %v\n", b.Name, b.Ref, err)
+			continue
+		}
+		dbs[b.Name] = db
+
+		data := col.PrepareSiteData(db)
+		data.Prefix = "/branches/" + b.Name
+		data.Branch = b.Name
+		// Commit and contributor pages only exist for the main tree.
+		data.CommitPages = main.CommitPages
+		data.ContributorStats = main.ContributorStats
+
+		dataHash := e.computeDataHash(data) + b.Name
+		root := "branches/" + b.Name + "/"
+		e.render(root+"index.html", views.CategoryList(data, e.cfg, root+"index.html"), cache.HashString(globalHash+dataHash+root))
+		for _, c := range db.Categories {
+			path := root + fmt.Sprintf("categories/%s/index.html", c.Name)
+			e.render(path, views.CategoryDetail(data, c, e.cfg, path), e.computeCategoryHash(c, globalHash, dataHash))
+		}
+		for _, p := range db.Ports {
+			path := root + fmt.Sprintf("ports/%s/%s/index.html", p.Category, p.Name)
+			e.render(path, views.PortDetail(data, p, e.cfg, path), e.computePortHash(p, globalHash, dataHash, data.SimplePortMap))
+		}
+	}
+
+	main.Comparisons = nil
+	mainHash := e.computeDataHash(main)
+	h := cache.NewHasher()
+	h.Add(globalHash + mainHash)
+	for _, spec := range e.cfg.Comparisons() {
+		from, to, ok := strings.Cut(spec, "...")
+		if !ok || dbs[from] == nil || dbs[to] == nil {
+			fmt.Printf("warning: invalid branch comparison %q\n", spec)
+			continue
+		}
+		cmp := compareDatabases(from, to, dbs[from], dbs[to])
+		main.Comparisons = append(main.Comparisons, cmp)
+
+		path := fmt.Sprintf("compare/%s...%s/index.html", from, to)
+		ch := e.computeComparisonHash(cmp, globalHash+mainHash+path)
+		e.render(path, views.Compare(main, cmp, e.cfg, path), ch)
+		h.Add(ch)
+	}
+	e.render("branches/index.html", views.BranchIndex(main, e.cfg, "branches/index.html"), h.Sum())
+}
+
+// renderReleases writes /releases/<tag>/ with the changes since the previous
+// matching tag. A page depends only on the commits of both tags, which are
+// only parsed when it is out of date.
+func (e *Engine) renderReleases(ctx context.Context, col *Collector, data *model.SiteData, globalHash string) {
+	if !e.cfg.Releases.Enabled {
+		return
+	}
+	gp, err := source.NewGitProvider(e.reg.PortsRoot())
+	if err != nil {
+		return
+	}
+	gp.SetMailmap(col.loadMailmap())
+
+	tags, err := gp.Tags()
+	if err != nil {
+		fmt.Printf("warning: could not list tags: %v\n", err)
+		return
+	}
+	var pattern *regexp.Regexp
+	if e.cfg.Releases.TagPattern != "" {
+		if pattern, err = regexp.Compile(e.cfg.Releases.TagPattern); err != nil {
+			fmt.Printf("warning: invalid release tag pattern: %v\n", err)
+			return
+		}
+	}
+
+	idx := cache.NewHasher()
+	idx.Add(globalHash)
+	data.Releases = nil
+	var prev source.Tag
+	for _, t := range tags {
+		if pattern != nil && !pattern.MatchString(t.Name) {
+			continue
+		}
+		data.Releases = append(data.Releases, &model.Changelog{From: prev.Name, To: t.Name, ToHash: t.Hash, Date: t.Date})
+
+		path := "releases/" + t.Name + "/index.html"
+		h := cache.HashString(globalHash + path + prev.Name + prev.Hash + t.Hash)
+		idx.Add(h)
+		from := prev.Name
+		prev = t
+
+		e.mu.Lock()
+		e.touched[path] = true
+		e.mu.Unlock()
+		if !e.manifest.HasChanged(path, h) {
+			continue
+		}
+
+		cl, err := col.changelog(ctx, gp, from, t.Name)
+		if err != nil {
+			fmt.Printf("warning: could not compare release %s: %v\n", t.Name, err)
+			continue
+		}
+		e.render(path, views.ReleasePage(data, cl, e.cfg, path), h)
+	}
+	e.render("releases/index.html", views.ReleaseIndex(data, e.cfg, "releases/index.html"), idx.Sum())
+}
+
+func (e *Engine) renderContributors(data *model.SiteData, globalHash, dataHash string) {
+	for _, prof := range data.Profiles {
+		path := fmt.Sprintf("contributors/%s/index.html", prof.Slug)
+		h := cache.NewHasher()
+		h.Add(globalHash + dataHash + path)
+		b, _ := json.Marshal(prof.Contributor)
+		h.AddBytes(b)
+		for _, p := range prof.Maintained {
+			h.Add(p.Hash)
+		}
+		e.render(path, views.ContributorPage(data, prof, e.cfg, path), h.Sum())
+	}
+}
+
+// renderCommitPages writes /commits/<hash>/ for the commits listed on the
+// site. A commit never changes, so a page is only rendered again when the
+// diff limits, the templates, the resolved identities and signature status or
+// the ports it links to change.
+func (e *Engine) renderCommitPages(col *Collector, data *model.SiteData, globalHash string) {
+	if len(data.CommitPages) == 0 {
+		return
+	}
+	gp, err := source.NewGitProvider(e.reg.PortsRoot())
+	if err != nil {
+		return
+	}
+
+	removed := make(map[string]bool, len(data.RemovedPorts))
+	for _, r := range data.RemovedPorts {
+		removed[r.Category+"/"+r.Name] = true
+	}
+	identity := col.loadMailmap().Hash() + ":" + col.loadKeyring().Hash()
+
+	for hash, c := range data.CommitPages {
+		path := fmt.Sprintf("commits/%s/index.html", hash)
+		files, err := gp.ChangedFiles(hash)
+		if err != nil {
+			fmt.Printf("warning: could not list the files of commit %s: %v\n", hash, err)
+		}
+		ports := touchedPorts(files, data.PortMap, removed)
+
+		h := cache.NewHasher()
+		h.Add(fmt.Sprintf("%s:%s:%d:%d:%s", globalHash, hash, e.cfg.CommitPages.MaxDiffBytes, e.cfg.CommitPages.MaxFiles, identity))
+		h.Add(c.Author + "<" + c.Email + ">" + c.SignedBy)
+		h.Add(string(c.Signature) + ":" + c.Type + ":" + c.Violation)
+		h.Add(strings.Join(ports, " "))
+		sum := h.Sum()
+
+		e.mu.Lock()
+		e.touched[path] = true
+		e.mu.Unlock()
+
+		if !e.manifest.HasChanged(path, sum) {
+			continue
+		}
+
+		diff, err := gp.Diff(hash, e.cfg.CommitPages.MaxDiffBytes, e.cfg.CommitPages.MaxFiles)
+		if err != nil {
+			fmt.Printf("warning: could not diff commit %s: %v\n", hash, err)
+		}
+		e.render(path, views.CommitPage(data, c, diff, ports, e.cfg, path), sum)
+	}
+}
+
+// touchedPorts returns the sorted keys of ports with a page that the given
+// files belong to.
+func touchedPorts(files []string, live map[string]*model.Port, removed map[string]bool) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, f := range files {
+		parts := strings.Split(f, "/")
+		if len(parts) < 2 {
+			continue
+		}
+		key := parts[0] + "/" + parts[1]
+		if seen[key] || (live[key] == nil && !removed[key]) {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (e *Engine) render(path string, comp templ.Component, hash string) {
 	e.mu.Lock()
 	e.touched[path] = true
@@ -309,7 +624,9 @@ func (e *Engine) renderFortunes(global string) {
 func (e *Engine) cleanup() {
 	for path := range e.manifest.Hashes {
 		if !e.touched[path] {
-			os.Remove(e.reg.PublicPage(path))
+			full := e.reg.PublicPage(path)
+			os.Remove(full)
+			os.Remove(filepath.Dir(full)) // only succeeds once the directory is empty
 			delete(e.manifest.Hashes, path)
 		}
 	}
@@ -351,6 +668,33 @@ func (e *Engine) computePortHash(p *model.Port, global, data string, pm map[stri
 	if p.CI != nil {
 		h.Add(p.CI.Status + fmt.Sprintf("%d", p.CI.BuildStarted))
 	}
+	if n := len(p.CIHistory); n > 0 {
+		h.Add(fmt.Sprintf("%d-%d-%d", n, p.CIHistory[n-1].Seen, p.CIFlips))
+	}
+	if p.Hardening != nil {
+		h.Add(fmt.Sprintf("%+v", *p.Hardening))
+	}
+	if l := p.Links; l != nil {
+		h.Add(strings.Join(l.Sonames, " ") + "|" + strings.Join(l.Unneeded, " ") + "|" + strings.Join(l.Unresolved, " "))
+		for _, m := range l.Missing {
+			h.Add(m.Soname + ">" + m.Port)
+		}
+	}
+	return h.Sum()
+}
+
+func (e *Engine) computeComparisonHash(cmp *model.BranchComparison, prefix string) string {
+	h := cache.NewHasher()
+	h.Add(prefix + fmt.Sprintf("%d", cmp.Unchanged))
+	for _, p := range cmp.Added {
+		h.Add("+" + p.Category + "/" + p.Name + "@" + p.Version)
+	}
+	for _, p := range cmp.Removed {
+		h.Add("-" + p.Category + "/" + p.Name + "@" + p.Version)
+	}
+	for _, c := range cmp.Changed {
+		h.Add("~" + c.To.Category + "/" + c.To.Name + "@" + c.From.Version + ">" + c.To.Version)
+	}
 	return h.Sum()
 }
 
@@ -359,6 +703,9 @@ func (e *Engine) computeCategoryHash(c *model.Category, global, data string) str
 	h.Add(global + data + c.Name)
 	for _, p := range c.Ports {
 		h.Add(p.Hash)
+		if p.CI != nil {
+			h.Add(p.CI.Status + fmt.Sprintf("%d", p.CI.BuildStarted))
+		}
 	}
 	return h.Sum()
 }
@@ -396,6 +743,8 @@ func (e *Engine) buildSearchIndex(ports []*model.Port) interface{} {
 	                Dt int64    `json:"dt,omitempty"`
 	                A  string   `json:"a,omitempty"`
 	                St string   `json:"st,omitempty"`
+	                Rg bool     `json:"rg,omitempty"`
+	                So []string `json:"so,omitempty"`
 	        }
 	        out := make([]Entry, 0, len(ports))
 	        for _, p := range ports {
@@ -410,16 +759,31 @@ func (e *Engine) buildSearchIndex(ports []*model.Port) interface{} {
 	                st := ""
 	                if p.CI != nil {
 	                        st = p.CI.Status
+	                }
+	                var so []string
+	                if p.Links != nil {
+	                        so = p.Links.Sonames
 	                }
 	                                        out = append(out, Entry{
 	                                                N: p.Name, C: p.Category, D: p.Description, V: p.Version,
 	                                                L: p.License, Ps: p.Provides, Ds: ds, Br: p.IsBroken,
 	                                                Un: p.IsUnmaintained, Dt: dt, A: p.Maintainer, St: st,
+	                                                Rg: len(p.Regressions) > 0, So: so,
 	                                        })
 	                                }
 	                        return out
 	                }
 
+// buildContributorIndex maps lowercased emails to profile slugs so that
+// client-side commit lists can link authors.
+func (e *Engine) buildContributorIndex(data *model.SiteData) map[string]string {
+	out := make(map[string]string, len(data.ContributorStats))
+	for email, c := range data.ContributorStats {
+		out[email] = c.Slug
+	}
+	return out
+}
+
 func copyFile(src, dst string) {
 	in, _ := os.Open(src)
 	defer in.Close()
@@ -0,0 +1,208 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"portsMaster/pkg/cache"
+	"portsMaster/pkg/highlight"
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+	"portsMaster/views"
+)
+
+// sniffBytes is how much of a file is inspected to tell text from binary.
+const sniffBytes = 8000
+
+// renderPortFiles renders the file browser of every port: a listing under
+// ports/<cat>/<name>/files/ and one highlighted page per text file under its
+// view/ directory, which keeps a port file named index.html from clashing
+// with the listing.
+func (e *Engine) renderPortFiles(col *Collector, data *model.SiteData, db *model.Database, globalHash, dataHash string) {
+	if !e.cfg.Files.Enabled {
+		return
+	}
+	blamer := e.newBlamer(col)
+	for _, p := range db.Ports {
+		dir := path.Join(p.Category, p.Name)
+		files, err := e.listPortFiles(dir, p, db.FileCommits)
+		if err != nil {
+			fmt.Printf("warning: could not list files of %s: %v\n", dir, err)
+			continue
+		}
+
+		root := "ports/" + dir + "/files/"
+		idx := cache.NewHasher()
+		idx.Add(globalHash + dataHash + root + p.Hash)
+		for _, f := range files {
+			commit := ""
+			if f.Commit != nil {
+				commit = f.Commit.Hash
+			}
+			idx.Add(f.Path + commit)
+			if f.Binary {
+				continue
+			}
+
+			page := root + "view/" + f.Path + "/index.html"
+			h := cache.HashString(globalHash + dataHash + page + p.Hash + commit)
+
+			e.mu.Lock()
+			e.touched[page] = true
+			e.mu.Unlock()
+			if !e.manifest.HasChanged(page, h) {
+				continue
+			}
+
+			content, truncated, err := readCapped(e.fsys, path.Join(dir, f.Path), e.cfg.Files.MaxBytes)
+			if err != nil {
+				fmt.Printf("warning: could not read %s/%s: %v\n", dir, f.Path, err)
+				continue
+			}
+			var lines []highlight.Line
+			if !truncated {
+				lines = highlight.File(f.Path, string(content))
+			}
+			e.render(page, views.PortFileView(data, p, f, lines, blamer != nil, e.cfg, page), h)
+		}
+		if blamer != nil {
+			blamer.render(data, p, files, globalHash)
+		}
+		path := root + "index.html"
+		e.render(path, views.PortFiles(data, p, files, blamer != nil, e.cfg, path), idx.Sum())
+	}
+}
+
+// blamer renders the blame pages of committed port files.
+type blamer struct {
+	e  *Engine
+	gp *source.GitProvider
+}
+
+// newBlamer returns nil when the ports tree is not a git repository.
+func (e *Engine) newBlamer(col *Collector) *blamer {
+	gp, err := source.NewGitProvider(e.reg.PortsRoot())
+	if err != nil {
+		return nil
+	}
+	gp.SetMailmap(col.loadMailmap())
+	gp = gp.At(e.cfg.Rev)
+	if _, _, err := gp.Revision(e.cfg.Rev); err != nil {
+		return nil
+	}
+	return &blamer{e: e, gp: gp}
+}
+
+// render writes ports/<cat>/<name>/files/view/<file>/blame.html for every
+// file with a known last commit. A page only changes with that commit and the
+// file's blob, so commits to other files leave it alone.
+func (b *blamer) render(data *model.SiteData, p *model.Port, files []*model.PortFile, globalHash string) {
+	e := b.e
+	dir := path.Join(p.Category, p.Name)
+	for _, f := range files {
+		if f.Binary || f.Commit == nil {
+			continue
+		}
+		page := "ports/" + dir + "/files/view/" + f.Path + "/blame.html"
+		blob, err := b.gp.BlobHash(path.Join(dir, f.Path))
+		if err != nil {
+			continue
+		}
+		h := cache.HashString(globalHash + b.gp.MailmapHash() + page + f.Commit.Hash + blob)
+
+		e.mu.Lock()
+		e.touched[page] = true
+		e.mu.Unlock()
+		if !e.manifest.HasChanged(page, h) {
+			continue
+		}
+
+		hunks, err := b.gp.BlameCached(path.Join(dir, f.Path), e.cfg.CacheDir)
+		if err != nil {
+			fmt.Printf("warning: could not blame %s/%s: %v\n", dir, f.Path, err)
+		}
+		var text []string
+		for _, hunk := range hunks {
+			text = append(text, hunk.Lines...)
+		}
+		lines := highlight.File(f.Path, strings.Join(text, "\n"))
+		for len(lines) < len(text) {
+			lines = append(lines, nil)
+		}
+		e.render(page, views.BlamePage(data, p, f, hunks, lines, e.cfg, page), h)
+	}
+}
+
+// listPortFiles walks a port directory and attaches to every file the last
+// commit that modified it, when that commit is in the port's history.
+func (e *Engine) listPortFiles(dir string, p *model.Port, fileCommits map[string]string) ([]*model.PortFile, error) {
+	byHash := make(map[string]*model.Commit, len(p.Commits))
+	for _, c := range p.Commits {
+		byHash[c.Hash] = c
+	}
+
+	var files []*model.PortFile
+	err := fs.WalkDir(e.fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		head, _, err := readCapped(e.fsys, name, sniffBytes)
+		if err != nil {
+			return err
+		}
+		files = append(files, &model.PortFile{
+			Path:   name[len(dir)+1:],
+			Size:   info.Size(),
+			Binary: isBinary(head),
+			Commit: byHash[fileCommits[name]],
+		})
+		return nil
+	})
+	return files, err
+}
+
+// readCapped reads at most max bytes of name and reports whether the file
+// is larger.
+func readCapped(fsys fs.FS, name string, max int64) ([]byte, bool, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, max+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(b)) > max {
+		return b[:max], true, nil
+	}
+	return b, false, nil
+}
+
+// isBinary guesses, like git, that content with a NUL byte is binary, and
+// also rejects content that is not UTF-8 apart from a rune cut at the end.
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size == 1 {
+			return len(head) >= utf8.UTFMax || utf8.FullRune(head)
+		}
+		head = head[size:]
+	}
+	return false
+}
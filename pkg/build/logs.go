@@ -0,0 +1,197 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"portsMaster/pkg/buildlog"
+	"portsMaster/pkg/cache"
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+// failureCacheVersion is bumped whenever the cached analysis changes shape.
+const failureCacheVersion = "1"
+
+type cachedFailures struct {
+	Version string                    `json:"version"`
+	Rules   string                    `json:"rules"`
+	Entries map[string]*cachedFailure `json:"entries"`
+}
+
+type cachedFailure struct {
+	Log     string              `json:"log"`
+	Started int64               `json:"started"`
+	Failure *model.BuildFailure `json:"failure"`
+}
+
+// logTimeout bounds the fetch of a remote build log.
+const logTimeout = time.Minute
+
+// maxRemoteLogBytes bounds the download of a remote log from a server that
+// does not serve ranges.
+const maxRemoteLogBytes = 64 << 20
+
+// logClient fetches remote build logs.
+var logClient = &http.Client{Timeout: logTimeout}
+
+// failureScanner analyzes the logs of failed builds. Build logs are named
+// by CI results, which may come from anywhere, so a log is only read when
+// it lives under log_root or the log directory of ci run. Each build is
+// analyzed once: results are kept in CacheDir until the rules change.
+type failureScanner struct {
+	analyzer *buildlog.Analyzer
+	maxBytes int64
+	logsURL  string
+	logDirs  []string
+	path     string
+	cache    cachedFailures
+	seen     map[string]bool
+}
+
+func (c *Collector) newFailureScanner() (*failureScanner, error) {
+	a, err := buildlog.NewAnalyzer(c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	rules, _ := json.Marshal(c.cfg.Logs)
+	f := &failureScanner{
+		analyzer: a,
+		maxBytes: c.cfg.Logs.MaxBytes,
+		path:     filepath.Join(c.cfg.CacheDir, "build_failures.json"),
+		seen:     make(map[string]bool),
+	}
+	_, runLogs := ciRunPaths(c.cfg)
+	roots := []string{runLogs}
+	if root := c.cfg.Metadata.LogsPath; config.IsRemote(root) {
+		f.logsURL = strings.TrimRight(root, "/") + "/"
+	} else if root != "" {
+		roots = append(roots, root)
+	}
+	for _, root := range roots {
+		if abs, err := filepath.Abs(root); err == nil {
+			f.logDirs = append(f.logDirs, abs)
+		}
+	}
+	if b, err := os.ReadFile(f.path); err == nil {
+		json.Unmarshal(b, &f.cache)
+	}
+	if f.cache.Version != failureCacheVersion || f.cache.Rules != cache.HashBytes(rules) {
+		f.cache = cachedFailures{Version: failureCacheVersion, Rules: cache.HashBytes(rules)}
+	}
+	if f.cache.Entries == nil {
+		f.cache.Entries = make(map[string]*cachedFailure)
+	}
+	return f, nil
+}
+
+// scan returns the failing step of the build of port key, or nil when its
+// log cannot be read.
+func (f *failureScanner) scan(ctx context.Context, key string, info *model.CIInfo) *model.BuildFailure {
+	f.seen[key] = true
+	if e, ok := f.cache.Entries[key]; ok && e.Log == info.BuildLog && e.Started == info.BuildStarted {
+		return e.Failure
+	}
+	if !f.readable(info.BuildLog) {
+		return nil
+	}
+
+	failure, err := f.analyze(ctx, info.BuildLog)
+	if err != nil {
+		fmt.Printf("warning: could not read build log of %s: %v\n", key, err)
+		return nil
+	}
+	f.cache.Entries[key] = &cachedFailure{Log: info.BuildLog, Started: info.BuildStarted, Failure: failure}
+	return failure
+}
+
+// analyze reads a local log, or the last maxBytes of a remote one.
+func (f *failureScanner) analyze(ctx context.Context, log string) (*model.BuildFailure, error) {
+	if !config.IsRemote(log) {
+		rc, err := os.Open(log)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return f.analyzer.Analyze(rc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, log, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=-%d", f.maxBytes))
+	resp, err := logClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		body := io.LimitReader(resp.Body, f.maxBytes)
+		if strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes 0-") {
+			return f.analyzer.Analyze(body)
+		}
+		return f.analyzer.AnalyzeTail(body)
+	case http.StatusOK:
+		// The server ignored the range.
+		return f.analyzer.Analyze(io.LimitReader(resp.Body, maxRemoteLogBytes))
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The log is empty.
+		return f.analyzer.Analyze(strings.NewReader(""))
+	}
+	return nil, fmt.Errorf("failed to fetch %s: %s", log, resp.Status)
+}
+
+// readable reports whether a log lies under log_root or the log directory
+// of ci run once cleaned, so results cannot point the scanner at other
+// files.
+func (f *failureScanner) readable(log string) bool {
+	if log == "" {
+		return false
+	}
+	if config.IsRemote(log) {
+		if f.logsURL == "" || !strings.HasPrefix(log, f.logsURL) {
+			return false
+		}
+		u, err := url.Parse(log)
+		return err == nil && !slices.Contains(strings.Split(u.Path, "/"), "..")
+	}
+	abs, err := filepath.Abs(filepath.Clean(log))
+	if err != nil {
+		return false
+	}
+	for _, dir := range f.logDirs {
+		if rel, err := filepath.Rel(dir, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// prune drops the ports that were not scanned, i.e. no longer fail.
+func (f *failureScanner) prune() {
+	for key := range f.cache.Entries {
+		if !f.seen[key] {
+			delete(f.cache.Entries, key)
+		}
+	}
+}
+
+func (f *failureScanner) save() {
+	b, err := json.Marshal(f.cache)
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(f.path), 0755)
+	_ = os.WriteFile(f.path, b, 0644)
+}
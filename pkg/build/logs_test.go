@@ -0,0 +1,128 @@
+package build
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+func testFailureScanner(t *testing.T, logsRoot string) (*failureScanner, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.New()
+	cfg.CacheDir = filepath.Join(dir, "cache")
+	cfg.OutDir = filepath.Join(dir, "public")
+	cfg.CI.Run.LogDir = filepath.Join(dir, "runlogs")
+	cfg.Metadata.LogsPath = logsRoot
+	cfg.Logs.MaxBytes = 64
+	f, err := (&Collector{cfg: cfg}).newFailureScanner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, dir
+}
+
+func TestFailureScannerReadable(t *testing.T) {
+	logsRoot := filepath.Join(t.TempDir(), "logs")
+	f, dir := testFailureScanner(t, logsRoot)
+	for log, want := range map[string]bool{
+		filepath.Join(logsRoot, "core/zlib.log"):    true,
+		filepath.Join(dir, "runlogs/core/zlib.log"): true,
+		logsRoot + "/core/../core/zlib.log":         true,
+		logsRoot + "/../secret":                     false,
+		logsRoot + "/core/../../secret":             false,
+		logsRoot + "-other/core/zlib.log":           false,
+		"/etc/shadow":                               false,
+		"~/.ssh/id_rsa":                             false,
+		"core/zlib.log":                             false,
+		"https://ci.example.org/logs/core/zlib.log": false,
+		"": false,
+		filepath.Join(dir, "runlogs", "..", "cache", "x.log"): false,
+	} {
+		if got := f.readable(log); got != want {
+			t.Errorf("readable(%q) = %v, want %v", log, got, want)
+		}
+	}
+
+	remote, _ := testFailureScanner(t, "https://ci.example.org/logs")
+	for log, want := range map[string]bool{
+		"https://ci.example.org/logs/core/zlib.log":        true,
+		"https://ci.example.org/logs/../admin":             false,
+		"https://ci.example.org/logs/%2e%2e/admin":         false,
+		"https://ci.example.org/logsx/core/zlib.log":       false,
+		"https://elsewhere.example.org/logs/core/zlib.log": false,
+		"/etc/shadow": false,
+	} {
+		if got := remote.readable(log); got != want {
+			t.Errorf("remote readable(%q) = %v, want %v", log, got, want)
+		}
+	}
+}
+
+func TestFailureScannerRemoteTail(t *testing.T) {
+	log := strings.Repeat("make: building\n", 20) + "foo.c:1:1: error: boom\nmake: *** [all] Error 1\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "zlib.log", time.Time{}, strings.NewReader(log))
+	}))
+	defer srv.Close()
+
+	f, _ := testFailureScanner(t, srv.URL+"/logs")
+	failure := f.scan(context.Background(), "core/zlib", &model.CIInfo{Status: "failed", BuildLog: srv.URL + "/logs/core/zlib.log"})
+	if failure == nil {
+		t.Fatal("no failure found")
+	}
+	if failure.Category != "compiler" || !failure.Partial {
+		t.Errorf("got %+v, want a partial compiler failure", failure)
+	}
+	for _, l := range failure.Excerpt {
+		if len(l) > 0 && !strings.HasPrefix(log[strings.Index(log, l):], l) {
+			t.Errorf("excerpt line %q is not in the log", l)
+		}
+	}
+	if strings.Contains(strings.Join(failure.Excerpt, "\n"), "ilding\nmake") {
+		t.Errorf("excerpt %q starts with a cut line", failure.Excerpt)
+	}
+}
+
+func TestFailureScannerLocal(t *testing.T) {
+	logsRoot := filepath.Join(t.TempDir(), "logs")
+	f, _ := testFailureScanner(t, logsRoot)
+	path := filepath.Join(logsRoot, "core", "zlib.log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("configure: error: no C compiler\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	failure := f.scan(context.Background(), "core/zlib", &model.CIInfo{Status: "failed", BuildLog: path})
+	if failure == nil || failure.Category != "configure" || failure.Line != 1 || failure.Partial {
+		t.Errorf("got %+v, want a configure failure on line 1", failure)
+	}
+	if got := f.scan(context.Background(), "core/evil", &model.CIInfo{Status: "failed", BuildLog: "/etc/passwd"}); got != nil {
+		t.Errorf("read a log outside the log directories: %+v", got)
+	}
+}
+
+func TestRelativeLog(t *testing.T) {
+	for log, want := range map[string]bool{
+		"":                             true,
+		"core/zlib.log":                true,
+		"https://ci.example.org/x.log": true,
+		"/etc/shadow":                  false,
+		"~/.ssh/id_rsa":                false,
+		"../secret":                    false,
+		"core/../../secret":            false,
+	} {
+		if got := relativeLog(log); got != want {
+			t.Errorf("relativeLog(%q) = %v, want %v", log, got, want)
+		}
+	}
+}
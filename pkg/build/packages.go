@@ -0,0 +1,71 @@
+package build
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path"
+	"sort"
+	"strings"
+
+	"portsMaster/pkg/cache"
+	"portsMaster/pkg/model"
+	"portsMaster/views"
+)
+
+// fileShards is the number of shards of the file index. search.js hashes
+// basenames the same way to pick the shard to load.
+const fileShards = 256
+
+// renderPackageContents renders the Contents page of every port with an
+// indexed package and the file index behind the file: search filter.
+func (e *Engine) renderPackageContents(data *model.SiteData, db *model.Database, globalHash, dataHash string) {
+	shards := make(map[string]map[string][][2]string)
+	for _, p := range db.Ports {
+		if !p.HasContents() {
+			continue
+		}
+		key := p.Category + "/" + p.Name
+
+		page := "ports/" + key + "/contents/index.html"
+		h := cache.NewHasher()
+		h.Add(globalHash + dataHash + page)
+		for _, pkg := range p.Packages {
+			h.Add(fmt.Sprintf("%s-%d", pkg.Filename, pkg.Size))
+			for _, f := range pkg.Files {
+				h.Add(fmt.Sprintf("%s-%d-%s", f.Path, f.Size, f.Link))
+			}
+		}
+		e.render(page, views.PackageContents(data, p, e.cfg, page), h.Sum())
+
+		for _, pkg := range p.Packages {
+			for _, f := range pkg.Files {
+				if f.Type == "dir" {
+					continue
+				}
+				base := strings.ToLower(f.Path[strings.LastIndex(f.Path, "/")+1:])
+				shard := fileShard(base)
+				if shards[shard] == nil {
+					shards[shard] = make(map[string][][2]string)
+				}
+				shards[shard][base] = append(shards[shard][base], [2]string{f.Path, key})
+			}
+		}
+	}
+
+	names := make([]string, 0, len(shards))
+	for name := range shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e.exportJSON(path.Join("files", name+".json"), shards[name], globalHash)
+	}
+}
+
+// fileShard returns the shard of a lowercased basename: its 32-bit FNV-1a
+// hash modulo fileShards, in hex.
+func fileShard(base string) string {
+	h := fnv.New32a()
+	h.Write([]byte(base))
+	return fmt.Sprintf("%02x", h.Sum32()%fileShards)
+}
@@ -0,0 +1,62 @@
+package build
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/repoindex"
+	"portsMaster/pkg/source"
+)
+
+// RepoIndex writes the package repository index of the local package root
+// and returns the directory it was written to and the number of packages.
+func (e *Engine) RepoIndex(ctx context.Context) (string, int, error) {
+	root := e.reg.PkgsRoot()
+	if root == "" || config.IsRemote(root) {
+		return "", 0, fmt.Errorf("repo index needs a local pkg_root")
+	}
+	if e.cfg.Rev != "" {
+		return "", 0, fmt.Errorf("repo index describes the packages on disk and cannot be pinned with --rev")
+	}
+
+	_, ports, err := e.scanner.Scan(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := source.ScanPackages(e.reg, ports); err != nil {
+		return "", 0, err
+	}
+
+	var key ed25519.PrivateKey
+	if e.cfg.Repo.SigningKey != "" {
+		if key, err = repoindex.LoadSigningKey(e.cfg.Repo.SigningKey); err != nil {
+			return "", 0, err
+		}
+	}
+	arch := e.cfg.Repo.Arch
+	if arch == "" {
+		arch = repoindex.HostArch()
+	}
+
+	sums := repoindex.OpenChecksums(filepath.Join(e.cfg.CacheDir, "repo_checksums.json"))
+	ix, err := repoindex.Build(root, ports, arch, sums, time.Now())
+	if err != nil {
+		return "", 0, err
+	}
+	if err := sums.Save(); err != nil {
+		fmt.Printf("warning: could not save package checksums: %v\n", err)
+	}
+
+	dir := e.cfg.Output
+	if dir == "" {
+		dir = e.cfg.Repo.Output
+	}
+	if dir == "" {
+		dir = root
+	}
+	return dir, len(ix.Packages), ix.Write(dir, key)
+}
@@ -0,0 +1,118 @@
+package buildlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+// DefaultRules classify the usual causes of a failed build. They are tried
+// in order, so specific rules come before generic ones.
+var DefaultRules = []config.LogRule{
+	{Category: "missing-header", Pattern: `fatal error: [^:]+\.h(pp)?: No such file or directory`},
+	{Category: "missing-dependency", Pattern: `Package '[^']+',? (required by '[^']+', )?not found|No package '[^']+' found|Could NOT find \w+`},
+	{Category: "linker", Pattern: `undefined reference to|collect2: error|ld(\.\w+)?: (error: )?cannot find|ld returned \d+ exit status`},
+	{Category: "download", Pattern: `checksum mismatch|[Cc]hecksum (verification )?failed|curl: \(\d+\)|wget: .*(failed|ERROR)`},
+	{Category: "configure", Pattern: `configure: error:|CMake Error|meson\.build:\d+:\d+: ERROR`},
+	{Category: "test", Pattern: `^FAIL:|[1-9]\d* tests? failed|# FAIL: +[1-9]|make(\[\d+\])?: \*\*\* \[[^\]]*(check|test)[^\]]*\]`},
+	{Category: "compiler", Pattern: `^\S+:\d+(:\d+)?: (fatal )?error: |error: expected|error: unknown type name`},
+}
+
+type rule struct {
+	category string
+	re       *regexp.Regexp
+}
+
+// Analyzer finds the failing step of a build log.
+type Analyzer struct {
+	rules    []rule
+	context  int
+	maxBytes int64
+}
+
+// NewAnalyzer compiles the configured rules, or DefaultRules when none are
+// configured.
+func NewAnalyzer(cfg *config.Config) (*Analyzer, error) {
+	rules := cfg.Logs.Rules
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	a := &Analyzer{context: cfg.Logs.Context, maxBytes: cfg.Logs.MaxBytes}
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log rule %q: %w", r.Category, err)
+		}
+		a.rules = append(a.rules, rule{category: r.Category, re: re})
+	}
+	return a, nil
+}
+
+// AnalyzeTail analyzes the end of a log read from an offset into it. The
+// first line is dropped as it is likely cut short, and the line numbers of
+// the result count from the start of the tail.
+func (a *Analyzer) AnalyzeTail(r io.Reader) (*model.BuildFailure, error) {
+	br := bufio.NewReader(r)
+	if _, err := br.ReadString('\n'); err != nil && err != io.EOF {
+		return nil, err
+	}
+	f, err := a.Analyze(br)
+	if err != nil {
+		return nil, err
+	}
+	f.Partial = true
+	return f, nil
+}
+
+// Analyze reads a log and returns the first line matched by the highest
+// priority rule, with the surrounding lines. Only the last maxBytes of the
+// log are kept. Without a match, the tail of the log is the excerpt.
+func (a *Analyzer) Analyze(r io.Reader) (*model.BuildFailure, error) {
+	var lines []string
+	first := 1 // line number of lines[0]
+	var size int64
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		l := sc.Text()
+		lines = append(lines, l)
+		size += int64(len(l)) + 1
+		for size > a.maxBytes && len(lines) > 1 {
+			size -= int64(len(lines[0])) + 1
+			lines = lines[1:]
+			first++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	f := &model.BuildFailure{Category: "unknown"}
+	match := -1
+	for _, r := range a.rules {
+		for i, l := range lines {
+			if r.re.MatchString(l) {
+				match = i
+				f.Category = r.category
+				break
+			}
+		}
+		if match >= 0 {
+			break
+		}
+	}
+
+	start, end := len(lines)-2*a.context-1, len(lines)
+	if match >= 0 {
+		f.Line = first + match
+		start, end = match-a.context, match+a.context+1
+	}
+	start, end = max(start, 0), min(end, len(lines))
+	f.ExcerptStart = first + start
+	f.Excerpt = append([]string(nil), lines[start:end]...)
+	return f, nil
+}
@@ -0,0 +1,78 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+// NewProvider returns the adapter configured by src.
+func NewProvider(src config.CISource) (model.CIProvider, error) {
+	if src.Path == "" {
+		return nil, fmt.Errorf("%s CI source has no path", src.Type)
+	}
+	var p model.CIProvider
+	switch src.Type {
+	case "", "json":
+		p = &JSONProvider{Path: src.Path}
+	case "junit":
+		p = &JUnitProvider{Dir: src.Path}
+	case "text":
+		p = &TextProvider{Path: src.Path}
+	case "jsonpath":
+		if src.Items == "" {
+			return nil, fmt.Errorf("jsonpath CI source %s has no items path", src.Path)
+		}
+		p = &JSONPathProvider{Path: src.Path, Items: src.Items, Fields: src.Fields}
+	default:
+		return nil, fmt.Errorf("unsupported CI source type: %s", src.Type)
+	}
+	if len(src.Statuses) > 0 {
+		p = &statusMapper{CIProvider: p, statuses: src.Statuses}
+	}
+	return p, nil
+}
+
+// Providers returns the CI sources of cfg in precedence order. Without
+// [[ci.sources]] the ci_status file is the only source.
+func Providers(cfg *config.Config) ([]model.CIProvider, error) {
+	sources := cfg.CI.Sources
+	if len(sources) == 0 {
+		path := cfg.CIStatus
+		if path == "" {
+			path = cfg.Metadata.CIStatus
+		}
+		if path == "" {
+			return nil, nil
+		}
+		sources = []config.CISource{{Type: "json", Path: path}}
+	}
+
+	var providers []model.CIProvider
+	for _, src := range sources {
+		p, err := NewProvider(src)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// statusMapper renames the statuses reported by a source.
+type statusMapper struct {
+	model.CIProvider
+	statuses map[string]string
+}
+
+func (m *statusMapper) Load(ctx context.Context) (map[string]*model.CIInfo, error) {
+	data, err := m.CIProvider.Load(ctx)
+	for _, info := range data {
+		if s, ok := m.statuses[info.Status]; ok {
+			info.Status = s
+		}
+	}
+	return data, err
+}
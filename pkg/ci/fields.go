@@ -0,0 +1,69 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"portsMaster/pkg/model"
+)
+
+// errUnknownField is returned by setField for names that are no CIInfo field.
+var errUnknownField = errors.New("unknown CI field")
+
+// setField assigns a textual value to the CIInfo field named like its JSON
+// key, or one of the short aliases used by the text format.
+func setField(info *model.CIInfo, name, value string) error {
+	switch name {
+	case "status":
+		info.Status = value
+	case "build_log", "log":
+		info.BuildLog = value
+	case "builder_info", "builder":
+		info.BuilderInfo = value
+	case "build_started", "started":
+		t, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		info.BuildStarted = t
+	default:
+		var dst *int64
+		switch name {
+		case "build_duration", "duration":
+			dst = &info.BuildDuration
+		case "size":
+			dst = &info.Size
+		case "installed_size":
+			dst = &info.InstalledSize
+		case "deps_size":
+			dst = &info.DepsSize
+		case "deps_installed_size":
+			dst = &info.DepsInstalledSize
+		default:
+			return fmt.Errorf("%w %q", errUnknownField, name)
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		*dst = int64(math.Round(f))
+	}
+	return nil
+}
+
+// parseTime accepts Unix seconds or an RFC 3339 timestamp, with or without
+// a zone (UTC assumed).
+func parseTime(value string) (int64, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time %q", value)
+}
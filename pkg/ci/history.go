@@ -0,0 +1,146 @@
+package ci
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+// History is the append-only record of CI results, one JSON-lines file per
+// port under dir/<category>/<name>.jsonl.
+type History struct {
+	dir string
+}
+
+// OpenHistory returns the history stored under dir.
+func OpenHistory(dir string) *History {
+	return &History{dir: dir}
+}
+
+// path returns the file of a port. Keys come from CI results, so anything
+// but a plain "category/name" is rejected rather than joined onto dir.
+func (h *History) path(key string) (string, error) {
+	cat, name, ok := strings.Cut(key, "/")
+	if !ok || cat == "" || name == "" || strings.Contains(name, "/") || strings.Contains(key, "..") || strings.ContainsRune(key, '\\') {
+		return "", fmt.Errorf("invalid port key %q", key)
+	}
+	return filepath.Join(h.dir, cat, name+".jsonl"), nil
+}
+
+// Record appends every result of a snapshot that differs from the last
+// record of its port, so re-reading an unchanged snapshot adds nothing.
+func (h *History) Record(data map[string]*model.CIInfo, now time.Time) error {
+	for key, info := range data {
+		if info == nil || info.Status == "" {
+			continue
+		}
+		records, err := h.Load(key)
+		if err != nil {
+			return err
+		}
+		if n := len(records); n > 0 {
+			last := records[n-1]
+			if last.Status == info.Status && last.Started == info.BuildStarted {
+				continue
+			}
+		}
+		rec := model.CIRecord{
+			Seen:      now.Unix(),
+			Status:    info.Status,
+			Started:   info.BuildStarted,
+			Duration:  info.BuildDuration,
+			Size:      info.Size,
+			Installed: info.InstalledSize,
+		}
+		if err := h.append(key, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *History) append(key string, rec model.CIRecord) error {
+	path, err := h.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	b, _ := json.Marshal(rec)
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load returns the records of a port, oldest first. Lines that cannot be
+// parsed, such as one cut short by a crash, are skipped.
+func (h *History) Load(key string) ([]model.CIRecord, error) {
+	path, err := h.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []model.CIRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec model.CIRecord
+		if json.Unmarshal(sc.Bytes(), &rec) == nil {
+			records = append(records, rec)
+		}
+	}
+	return records, sc.Err()
+}
+
+// BrokenSince returns when the current run of failures began, or 0 when
+// the latest record did not fail.
+func BrokenSince(records []model.CIRecord, v config.CIVocabulary) int64 {
+	var since int64
+	for i := len(records) - 1; i >= 0 && v.Failing(records[i].Status); i-- {
+		since = records[i].Time()
+	}
+	return since
+}
+
+// Flips counts how often the outcome switched between passing and failing
+// over the last n records. Records that neither passed nor failed, such as
+// skipped builds, are ignored.
+func Flips(records []model.CIRecord, n int, v config.CIVocabulary) int {
+	if len(records) > n {
+		records = records[len(records)-n:]
+	}
+	flips := 0
+	var prev string
+	for _, r := range records {
+		if !v.Passing(r.Status) && !v.Failing(r.Status) {
+			continue
+		}
+		if prev != "" && v.Failing(r.Status) != v.Failing(prev) {
+			flips++
+		}
+		prev = r.Status
+	}
+	return flips
+}
@@ -0,0 +1,76 @@
+package ci
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"portsMaster/pkg/model"
+)
+
+// Inbox keeps the results pushed to the server, keyed by "category/name",
+// so that they outlive the process and apply to every later build until a
+// configured source reports a newer build of the port.
+type Inbox struct {
+	path string
+}
+
+// OpenInbox returns the inbox stored in the file at path.
+func OpenInbox(path string) *Inbox {
+	return &Inbox{path: path}
+}
+
+// Load returns every result received so far.
+func (in *Inbox) Load() (map[string]*model.CIInfo, error) {
+	b, err := os.ReadFile(in.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]*model.CIInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]*model.CIInfo)
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Add stores results, replacing earlier results of the same ports.
+func (in *Inbox) Add(results map[string]*model.CIInfo) error {
+	data, err := in.Load()
+	if err != nil {
+		return err
+	}
+	for key, info := range results {
+		data[key] = info
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(in.path), 0755); err != nil {
+		return err
+	}
+	tmp := in.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, in.path)
+}
+
+// Overlay replaces the results of data with received ones that are at least
+// as recent.
+func Overlay(data, received map[string]*model.CIInfo) {
+	for key, info := range received {
+		if info == nil {
+			continue
+		}
+		if cur, ok := data[key]; ok && cur.BuildStarted > info.BuildStarted {
+			continue
+		}
+		c := *info
+		data[key] = &c
+	}
+}
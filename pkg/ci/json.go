@@ -0,0 +1,20 @@
+package ci
+
+import (
+	"context"
+
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+)
+
+// JSONProvider reads the native format: a JSON object mapping
+// "category/name" to CIInfo.
+type JSONProvider struct {
+	Path string
+}
+
+func (p *JSONProvider) Type() string { return "json" }
+
+func (p *JSONProvider) Load(ctx context.Context) (map[string]*model.CIInfo, error) {
+	return source.LoadCIStatus(p.Path)
+}
@@ -0,0 +1,183 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+)
+
+// JSONPathProvider maps an arbitrary JSON document to CI results. Items
+// selects the build records, e.g. "$.builds"; when it selects an object,
+// each member is a record keyed by its member name. Fields maps CIInfo JSON
+// keys, plus "key" or "category" and "name", to paths inside a record.
+type JSONPathProvider struct {
+	Path   string
+	Items  string
+	Fields map[string]string
+}
+
+func (p *JSONPathProvider) Type() string { return "jsonpath" }
+
+func (p *JSONPathProvider) Load(ctx context.Context) (map[string]*model.CIInfo, error) {
+	rc, err := source.Open(ctx, p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var doc any
+	if err := json.NewDecoder(rc).Decode(&doc); err != nil {
+		return nil, err
+	}
+	nodes, err := lookup(doc, p.Items)
+	if err != nil {
+		return nil, err
+	}
+	items, keys := records(nodes)
+
+	data := make(map[string]*model.CIInfo)
+	for i, item := range items {
+		info := &model.CIInfo{}
+		for field, path := range p.Fields {
+			if field == "key" || field == "category" || field == "name" {
+				continue
+			}
+			v, err := lookupValue(item, path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Path, err)
+			}
+			if v == "" {
+				continue
+			}
+			if err := setField(info, field, v); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Path, err)
+			}
+		}
+
+		key := keys[i]
+		if path, ok := p.Fields["key"]; ok {
+			key, _ = lookupValue(item, path)
+		} else if p.Fields["category"] != "" && p.Fields["name"] != "" {
+			cat, _ := lookupValue(item, p.Fields["category"])
+			name, _ := lookupValue(item, p.Fields["name"])
+			if cat != "" && name != "" {
+				key = cat + "/" + name
+			}
+		}
+		if key != "" {
+			data[key] = info
+		}
+	}
+	return data, nil
+}
+
+// records turns the nodes selected by Items into build records. A single
+// array or object selected without [*] stands for its elements or members;
+// members are keyed by name.
+func records(nodes []any) ([]any, []string) {
+	if len(nodes) == 1 {
+		switch v := nodes[0].(type) {
+		case []any:
+			return v, make([]string, len(v))
+		case map[string]any:
+			keys := sortedKeys(v)
+			items := make([]any, len(keys))
+			for i, k := range keys {
+				items[i] = v[k]
+			}
+			return items, keys
+		}
+	}
+	return nodes, make([]string, len(nodes))
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookup evaluates a small JSONPath subset: an optional leading "$",
+// member names separated by dots, and [n] or [*] indexes.
+func lookup(doc any, path string) ([]any, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	nodes := []any{doc}
+	for path != "" {
+		var seg string
+		switch {
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in %q", path)
+			}
+			seg, path = path[:end+1], path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			seg, path = path[:end], path[end:]
+		}
+		path = strings.TrimPrefix(path, ".")
+
+		var next []any
+		for _, n := range nodes {
+			switch {
+			case seg == "[*]":
+				switch v := n.(type) {
+				case []any:
+					next = append(next, v...)
+				case map[string]any:
+					for _, k := range sortedKeys(v) {
+						next = append(next, v[k])
+					}
+				}
+			case seg[0] == '[':
+				i, err := strconv.Atoi(seg[1 : len(seg)-1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %s", seg)
+				}
+				if arr, ok := n.([]any); ok && i >= 0 && i < len(arr) {
+					next = append(next, arr[i])
+				}
+			default:
+				if obj, ok := n.(map[string]any); ok {
+					if v, ok := obj[seg]; ok {
+						next = append(next, v)
+					}
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// lookupValue returns the first value at path as text.
+func lookupValue(doc any, path string) (string, error) {
+	nodes, err := lookup(doc, path)
+	if err != nil || len(nodes) == 0 {
+		return "", err
+	}
+	switch v := nodes[0].(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("%s is not a scalar", path)
+	}
+}
@@ -0,0 +1,137 @@
+package ci
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"portsMaster/pkg/model"
+)
+
+// JUnitProvider reads a directory of JUnit XML reports in which every test
+// case is a port build. A case is keyed by its name when that reads
+// "category/name", and by "<last classname segment>/<name>" otherwise.
+// Case properties named like CIInfo fields (build_log, size, ...) are
+// applied too; other properties are ignored.
+type JUnitProvider struct {
+	Dir string
+}
+
+func (p *JUnitProvider) Type() string { return "junit" }
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Timestamp string       `xml:"timestamp,attr"`
+	Hostname  string       `xml:"hostname,attr"`
+	Cases     []junitCase  `xml:"testcase"`
+	Suites    []junitSuite `xml:"testsuite"`
+}
+
+type junitCase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Failure    *struct{}       `xml:"failure"`
+	Error      *struct{}       `xml:"error"`
+	Skipped    *struct{}       `xml:"skipped"`
+	Properties []junitProperty `xml:"properties>property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func (p *JUnitProvider) Load(ctx context.Context) (map[string]*model.CIInfo, error) {
+	data := make(map[string]*model.CIInfo)
+	err := filepath.WalkDir(p.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".xml") {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		// Reports either wrap suites in <testsuites> or are a single suite.
+		var root junitSuites
+		if strings.Contains(string(b), "<testsuites") {
+			err = xml.Unmarshal(b, &root)
+		} else {
+			var s junitSuite
+			err = xml.Unmarshal(b, &s)
+			root.Suites = []junitSuite{s}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, s := range root.Suites {
+			if err := addSuite(data, s); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return nil
+	})
+	return data, err
+}
+
+func addSuite(data map[string]*model.CIInfo, s junitSuite) error {
+	for _, sub := range s.Suites {
+		if sub.Timestamp == "" {
+			sub.Timestamp = s.Timestamp
+		}
+		if err := addSuite(data, sub); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range s.Cases {
+		key := c.Name
+		if !strings.Contains(key, "/") {
+			class := c.Classname[strings.LastIndex(c.Classname, ".")+1:]
+			if class == "" {
+				continue
+			}
+			key = class + "/" + c.Name
+		}
+
+		info := &model.CIInfo{Status: "success", BuilderInfo: s.Hostname}
+		switch {
+		case c.Failure != nil || c.Error != nil:
+			info.Status = "failed"
+		case c.Skipped != nil:
+			info.Status = "skipped"
+		}
+		if c.Time != "" {
+			if err := setField(info, "build_duration", c.Time); err != nil {
+				return err
+			}
+		}
+		if s.Timestamp != "" {
+			if err := setField(info, "build_started", s.Timestamp); err != nil {
+				return err
+			}
+		}
+		for _, prop := range c.Properties {
+			if err := setField(info, prop.Name, prop.Value); err != nil && !errors.Is(err, errUnknownField) {
+				return err
+			}
+		}
+		data[key] = info
+	}
+	return nil
+}
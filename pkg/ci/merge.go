@@ -0,0 +1,94 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+
+	"portsMaster/pkg/model"
+)
+
+// LoadAll loads every provider and merges the results. A failing source is
+// reported as a warning and skipped.
+func LoadAll(ctx context.Context, providers []model.CIProvider, mode string) (map[string]*model.CIInfo, error) {
+	var results []map[string]*model.CIInfo
+	for _, p := range providers {
+		data, err := p.Load(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fmt.Printf("warning: could not load %s CI results: %v\n", p.Type(), err)
+			continue
+		}
+		results = append(results, data)
+	}
+	return Merge(results, mode)
+}
+
+// Merge combines results listed in precedence order:
+//
+//   - "first" (default): the first source reporting a port wins.
+//   - "newest": the result with the latest build start wins, ties going
+//     to the earlier source.
+//   - "fill": like "first", but fields the winner leaves empty are taken
+//     from later sources.
+func Merge(results []map[string]*model.CIInfo, mode string) (map[string]*model.CIInfo, error) {
+	merged := make(map[string]*model.CIInfo)
+	for _, data := range results {
+		for key, info := range data {
+			if info == nil {
+				continue
+			}
+			cur, ok := merged[key]
+			if !ok {
+				c := *info
+				merged[key] = &c
+				continue
+			}
+			switch mode {
+			case "", "first":
+			case "newest":
+				if info.BuildStarted > cur.BuildStarted {
+					c := *info
+					merged[key] = &c
+				}
+			case "fill":
+				fill(cur, info)
+			default:
+				return nil, fmt.Errorf("unknown CI merge mode %q", mode)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// fill copies into dst the fields it leaves empty.
+func fill(dst, src *model.CIInfo) {
+	if dst.Status == "" {
+		dst.Status = src.Status
+	}
+	if dst.BuildLog == "" {
+		dst.BuildLog = src.BuildLog
+	}
+	if dst.BuildDuration == 0 {
+		dst.BuildDuration = src.BuildDuration
+	}
+	if dst.BuildStarted == 0 {
+		dst.BuildStarted = src.BuildStarted
+	}
+	if dst.Size == 0 {
+		dst.Size = src.Size
+	}
+	if dst.BuilderInfo == "" {
+		dst.BuilderInfo = src.BuilderInfo
+	}
+	if dst.InstalledSize == 0 {
+		dst.InstalledSize = src.InstalledSize
+	}
+	if dst.DepsSize == 0 {
+		dst.DepsSize = src.DepsSize
+	}
+	if dst.DepsInstalledSize == 0 {
+		dst.DepsInstalledSize = src.DepsInstalledSize
+	}
+}
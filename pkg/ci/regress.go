@@ -0,0 +1,92 @@
+package ci
+
+import (
+	"sort"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+// minBaseline is how many earlier successful builds a metric needs before
+// its latest value is compared with them.
+const minBaseline = 3
+
+// Thresholds configures regression detection; see config.CI.Regressions.
+type Thresholds struct {
+	Window      int
+	Duration    float64
+	Size        float64
+	MinDuration int64
+	MinSize     int64
+}
+
+// Regressions compares the latest record, when it is a successful build,
+// with the median of the successful builds before it and returns the
+// metrics that grew past their threshold.
+func Regressions(records []model.CIRecord, t Thresholds, v config.CIVocabulary) []model.Regression {
+	var ok []model.CIRecord
+	for _, r := range records {
+		if v.Passing(r.Status) {
+			ok = append(ok, r)
+		}
+	}
+	if len(records) == 0 || !v.Passing(records[len(records)-1].Status) || len(ok) <= minBaseline {
+		return nil
+	}
+
+	metrics := []struct {
+		name      string
+		value     func(model.CIRecord) int64
+		threshold float64
+		min       int64
+	}{
+		{"duration", func(r model.CIRecord) int64 { return r.Duration }, t.Duration, t.MinDuration},
+		{"size", func(r model.CIRecord) int64 { return r.Size }, t.Size, t.MinSize},
+		{"installed_size", func(r model.CIRecord) int64 { return r.Installed }, t.Size, t.MinSize},
+	}
+
+	var out []model.Regression
+	for _, m := range metrics {
+		series := make([]int64, len(ok))
+		for i, r := range ok {
+			series[i] = m.value(r)
+		}
+		current := series[len(series)-1]
+		if current == 0 || current < m.min {
+			continue
+		}
+		start := 0
+		if t.Window > 0 {
+			start = max(len(series)-1-t.Window, 0)
+		}
+		baseline := median(series[start : len(series)-1])
+		if baseline == 0 {
+			continue
+		}
+		reg := model.Regression{Metric: m.name, Baseline: baseline, Current: current, Series: series[start:]}
+		if reg.Change() > m.threshold {
+			out = append(out, reg)
+		}
+	}
+	return out
+}
+
+// median returns the median of the non-zero values, or 0 when fewer than
+// minBaseline are known.
+func median(values []int64) int64 {
+	var known []int64
+	for _, v := range values {
+		if v > 0 {
+			known = append(known, v)
+		}
+	}
+	if len(known) < minBaseline {
+		return 0
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i] < known[j] })
+	n := len(known)
+	if n%2 == 1 {
+		return known[n/2]
+	}
+	return (known[n/2-1] + known[n/2]) / 2
+}
@@ -0,0 +1,273 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"portsMaster/pkg/model"
+)
+
+// Runner builds ports in dependency order with a pool of workers. Every
+// port runs Command through sh -c in its directory, with its output
+// captured to a log file and its artifacts collected in a directory of
+// their own.
+type Runner struct {
+	Command     string
+	Workers     int
+	Timeout     time.Duration
+	PortsRoot   string
+	LogDir      string
+	ArtifactDir string
+	Builder     string
+
+	// LogPath turns the path of a log file into the BuildLog to report.
+	LogPath func(path string) string
+	// Progress, when set, is told about every finished port.
+	Progress func(key string, info *model.CIInfo)
+}
+
+type buildResult struct {
+	key  string
+	info *model.CIInfo
+}
+
+// Run builds ports and returns their results keyed by "category/name".
+// Ports marked BROKEN are not built, ports depending on a port that did not
+// build successfully are reported as dependency-failed and ports caught in
+// a dependency cycle are skipped.
+func (r *Runner) Run(ctx context.Context, ports []*model.Port) map[string]*model.CIInfo {
+	byKey := make(map[string]*model.Port, len(ports))
+	byName := make(map[string]string)
+	for _, p := range ports {
+		key := p.Category + "/" + p.Name
+		byKey[key] = p
+		if _, ok := byName[p.Name]; !ok {
+			byName[p.Name] = key
+		}
+	}
+	for _, p := range ports {
+		for _, prov := range p.Provides {
+			if _, ok := byName[prov]; !ok {
+				byName[prov] = p.Category + "/" + p.Name
+			}
+		}
+	}
+
+	// Dependencies outside the tree are provided by the host.
+	pending := make(map[string]int, len(ports))
+	dependents := make(map[string][]string)
+	for key, p := range byKey {
+		seen := make(map[string]bool)
+		for _, d := range p.Deps {
+			dep, ok := byName[d.Name]
+			if !ok || dep == key || seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			pending[key]++
+			dependents[dep] = append(dependents[dep], key)
+		}
+	}
+
+	workers := max(r.Workers, 1)
+	jobs := make(chan *model.Port, len(ports))
+	results := make(chan buildResult, len(ports))
+	for range workers {
+		go func() {
+			for p := range jobs {
+				results <- buildResult{p.Category + "/" + p.Name, r.build(ctx, p)}
+			}
+		}()
+	}
+	defer close(jobs)
+
+	done := make(map[string]*model.CIInfo, len(ports))
+	running := 0
+	var finish func(key string, info *model.CIInfo)
+	start := func(key string) {
+		p := byKey[key]
+		if p.IsBroken {
+			finish(key, r.skip(key, "broken", "marked BROKEN"))
+			return
+		}
+		running++
+		jobs <- p
+	}
+	finish = func(key string, info *model.CIInfo) {
+		done[key] = info
+		if r.Progress != nil {
+			r.Progress(key, info)
+		}
+		for _, d := range dependents[key] {
+			if _, ok := done[d]; ok {
+				continue
+			}
+			if info.Status != "success" {
+				finish(d, r.skip(d, "dependency-failed", "dependency "+key+" did not build"))
+				continue
+			}
+			if pending[d]--; pending[d] == 0 {
+				start(d)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if pending[key] == 0 {
+			if _, ok := done[key]; !ok {
+				start(key)
+			}
+		}
+	}
+	for running > 0 {
+		res := <-results
+		running--
+		finish(res.key, res.info)
+	}
+
+	// What is left waits on a cycle, directly or through a dependency. None
+	// of it failed, so it is skipped as a whole rather than cascaded.
+	for _, key := range keys {
+		if _, ok := done[key]; !ok {
+			info := r.skip(key, "skipped", "dependency cycle")
+			done[key] = info
+			if r.Progress != nil {
+				r.Progress(key, info)
+			}
+		}
+	}
+	r.addDepsSizes(done, byKey, byName)
+	return done
+}
+
+// build runs the build command for one port.
+func (r *Runner) build(ctx context.Context, p *model.Port) *model.CIInfo {
+	key := p.Category + "/" + p.Name
+	info := &model.CIInfo{Status: "failed", BuilderInfo: r.Builder, BuildStarted: time.Now().Unix()}
+
+	logPath := filepath.Join(r.LogDir, p.Category, p.Name+".log")
+	artifacts := filepath.Join(r.ArtifactDir, p.Category, p.Name)
+	os.RemoveAll(artifacts)
+	if err := os.MkdirAll(artifacts, 0755); err != nil {
+		r.writeLog(logPath, fmt.Sprintf("error: %v\n", err))
+		info.BuildLog = r.LogPath(logPath)
+		return info
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return info
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return info
+	}
+	defer logFile.Close()
+	info.BuildLog = r.LogPath(logPath)
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	dir := filepath.Join(r.PortsRoot, p.Category, p.Name)
+	cmd := exec.CommandContext(ctx, "sh", "-c", r.Command)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"PORT_CATEGORY="+p.Category,
+		"PORT_NAME="+p.Name,
+		"PORT_VERSION="+p.Version,
+		"PORT_RELEASE="+p.Release,
+		"PORT_DIR="+dir,
+		"ARTIFACT_DIR="+artifacts,
+	)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.WaitDelay = 5 * time.Second
+
+	fmt.Fprintf(logFile, "==> %s %s-%s: %s\n", key, p.Version, p.Release, r.Command)
+	started := time.Now()
+	err = cmd.Run()
+	info.BuildDuration = int64(time.Since(started).Round(time.Second) / time.Second)
+
+	switch {
+	case err == nil:
+		info.Status = "success"
+		info.Size = dirSize(artifacts)
+		fmt.Fprintf(logFile, "==> %s built in %s\n", key, time.Since(started).Round(time.Millisecond))
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		info.Status = "timeout"
+		fmt.Fprintf(logFile, "==> %s timed out after %s\n", key, r.Timeout)
+	default:
+		fmt.Fprintf(logFile, "==> %s failed: %v\n", key, err)
+	}
+	return info
+}
+
+// skip records a port that was not built, with the status to report and
+// why.
+func (r *Runner) skip(key, status, reason string) *model.CIInfo {
+	info := &model.CIInfo{Status: status, BuilderInfo: r.Builder, BuildStarted: time.Now().Unix()}
+	logPath := filepath.Join(r.LogDir, filepath.FromSlash(key)+".log")
+	if r.writeLog(logPath, fmt.Sprintf("==> %s skipped: %s\n", key, reason)) == nil {
+		info.BuildLog = r.LogPath(logPath)
+	}
+	return info
+}
+
+func (r *Runner) writeLog(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(text), 0644)
+}
+
+// addDepsSizes sums the artifact sizes of every port's transitive
+// dependencies.
+func (r *Runner) addDepsSizes(done map[string]*model.CIInfo, byKey map[string]*model.Port, byName map[string]string) {
+	for key, info := range done {
+		if info.Status != "success" {
+			continue
+		}
+		seen := map[string]bool{key: true}
+		queue := []string{key}
+		for len(queue) > 0 {
+			p := byKey[queue[0]]
+			queue = queue[1:]
+			for _, d := range p.Deps {
+				dep, ok := byName[d.Name]
+				if !ok || seen[dep] {
+					continue
+				}
+				seen[dep] = true
+				queue = append(queue, dep)
+				if di := done[dep]; di != nil {
+					info.DepsSize += di.Size
+				}
+			}
+		}
+	}
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,180 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"portsMaster/pkg/model"
+)
+
+func testPort(t *testing.T, r *Runner, key string, deps ...string) *model.Port {
+	t.Helper()
+	cat, name, _ := strings.Cut(key, "/")
+	p := &model.Port{Category: cat, Name: name, Version: "1.0", Release: "1"}
+	for _, d := range deps {
+		p.Deps = append(p.Deps, model.Dependency{Name: d, Type: model.DepLink})
+	}
+	if err := os.MkdirAll(filepath.Join(r.PortsRoot, cat, name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testRunner(t *testing.T, command string) *Runner {
+	t.Helper()
+	dir := t.TempDir()
+	return &Runner{
+		Command:     command,
+		Workers:     4,
+		PortsRoot:   filepath.Join(dir, "ports"),
+		LogDir:      filepath.Join(dir, "logs"),
+		ArtifactDir: filepath.Join(dir, "artifacts"),
+		Builder:     "test",
+		LogPath:     func(path string) string { return path },
+	}
+}
+
+func checkStatus(t *testing.T, results map[string]*model.CIInfo, want map[string]string) {
+	t.Helper()
+	if len(results) != len(want) {
+		t.Errorf("got %d results, want %d", len(results), len(want))
+	}
+	for key, status := range want {
+		info, ok := results[key]
+		if !ok {
+			t.Errorf("%s: no result", key)
+			continue
+		}
+		if info.Status != status {
+			t.Errorf("%s: status %q, want %q", key, info.Status, status)
+		}
+		if info.BuildLog == "" {
+			t.Errorf("%s: no build log", key)
+		}
+	}
+}
+
+func TestRunnerDependencyOrder(t *testing.T) {
+	order := filepath.Join(t.TempDir(), "order")
+	r := testRunner(t, fmt.Sprintf(`echo "$PORT_NAME" >> %q && echo artifact > "$ARTIFACT_DIR/out"`, order))
+	ports := []*model.Port{
+		testPort(t, r, "net/curl", "openssl", "zlib"),
+		testPort(t, r, "core/openssl", "zlib"),
+		testPort(t, r, "core/zlib"),
+		testPort(t, r, "core/musl"),
+	}
+
+	results := r.Run(context.Background(), ports)
+	checkStatus(t, results, map[string]string{
+		"net/curl":     "success",
+		"core/openssl": "success",
+		"core/zlib":    "success",
+		"core/musl":    "success",
+	})
+
+	b, err := os.ReadFile(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := make(map[string]int)
+	for i, name := range strings.Fields(string(b)) {
+		pos[name] = i
+	}
+	if len(pos) != 4 {
+		t.Fatalf("built %q, want every port once", b)
+	}
+	if pos["zlib"] > pos["openssl"] || pos["openssl"] > pos["curl"] {
+		t.Errorf("built in order %q, want zlib before openssl before curl", b)
+	}
+
+	if got := results["net/curl"].DepsSize; got != 2*results["core/zlib"].Size || got == 0 {
+		t.Errorf("curl DepsSize = %d, want the size of openssl and zlib", got)
+	}
+}
+
+func TestRunnerDependencyFailed(t *testing.T) {
+	r := testRunner(t, `test "$PORT_NAME" != zlib`)
+	ports := []*model.Port{
+		testPort(t, r, "core/zlib"),
+		testPort(t, r, "core/openssl", "zlib"),
+		testPort(t, r, "net/curl", "openssl"),
+		testPort(t, r, "core/musl"),
+	}
+
+	results := r.Run(context.Background(), ports)
+	checkStatus(t, results, map[string]string{
+		"core/zlib":    "failed",
+		"core/openssl": "dependency-failed",
+		"net/curl":     "dependency-failed",
+		"core/musl":    "success",
+	})
+}
+
+func TestRunnerBroken(t *testing.T) {
+	built := filepath.Join(t.TempDir(), "built")
+	r := testRunner(t, fmt.Sprintf(`echo "$PORT_NAME" >> %q`, built))
+	zlib := testPort(t, r, "core/zlib")
+	zlib.IsBroken = true
+	ports := []*model.Port{zlib, testPort(t, r, "core/openssl", "zlib")}
+
+	results := r.Run(context.Background(), ports)
+	checkStatus(t, results, map[string]string{
+		"core/zlib":    "broken",
+		"core/openssl": "dependency-failed",
+	})
+	if _, err := os.Stat(built); !os.IsNotExist(err) {
+		t.Errorf("the command ran for a broken port or its dependents")
+	}
+}
+
+func TestRunnerCycle(t *testing.T) {
+	r := testRunner(t, "true")
+	ports := []*model.Port{
+		testPort(t, r, "core/a", "b"),
+		testPort(t, r, "core/b", "a"),
+		testPort(t, r, "core/c", "a"),
+		testPort(t, r, "core/d"),
+	}
+
+	results := r.Run(context.Background(), ports)
+	checkStatus(t, results, map[string]string{
+		"core/a": "skipped",
+		"core/b": "skipped",
+		"core/c": "skipped",
+		"core/d": "success",
+	})
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	r := testRunner(t, `if [ "$PORT_NAME" = slow ]; then exec sleep 30; fi`)
+	r.Timeout = 200 * time.Millisecond
+	ports := []*model.Port{
+		testPort(t, r, "core/slow"),
+		testPort(t, r, "core/fast"),
+		testPort(t, r, "core/after", "slow"),
+	}
+
+	started := time.Now()
+	results := r.Run(context.Background(), ports)
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("run took %s, want the slow port stopped after its timeout", elapsed)
+	}
+	checkStatus(t, results, map[string]string{
+		"core/slow":  "timeout",
+		"core/fast":  "success",
+		"core/after": "dependency-failed",
+	})
+
+	b, err := os.ReadFile(results["core/slow"].BuildLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "timed out") {
+		t.Errorf("log %q does not report the timeout", b)
+	}
+}
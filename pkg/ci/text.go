@@ -0,0 +1,56 @@
+package ci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/source"
+)
+
+// TextProvider reads a line-oriented index, one port per line:
+//
+//	category/name status [field=value ...]
+//
+// Fields are CIInfo JSON keys or the aliases duration, started, log and
+// builder. Blank lines and lines starting with # are ignored.
+type TextProvider struct {
+	Path string
+}
+
+func (p *TextProvider) Type() string { return "text" }
+
+func (p *TextProvider) Load(ctx context.Context) (map[string]*model.CIInfo, error) {
+	rc, err := source.Open(ctx, p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data := make(map[string]*model.CIInfo)
+	sc := bufio.NewScanner(rc)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			return nil, fmt.Errorf("%s:%d: expected \"category/name status\"", p.Path, n)
+		}
+		info := &model.CIInfo{Status: fields[1]}
+		for _, f := range fields[2:] {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected field=value, got %q", p.Path, n, f)
+			}
+			if err := setField(info, k, v); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", p.Path, n, err)
+			}
+		}
+		data[fields[0]] = info
+	}
+	return data, sc.Err()
+}
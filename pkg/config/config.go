@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"portsMaster/pkg/util"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -16,6 +19,22 @@ type NavLink struct {
 	URL  string `toml:"url"`
 }
 
+// Alias maps alternative names and emails onto one canonical contributor
+// identity. Aliases take precedence over the ports tree's .mailmap.
+type Alias struct {
+	Name   string   `toml:"name"`
+	Email  string   `toml:"email"`
+	Emails []string `toml:"emails"`
+	Names  []string `toml:"names"`
+}
+
+// Branch is an extra git ref of the ports tree, rendered under
+// /branches/<name>/ next to the main site.
+type Branch struct {
+	Name string `toml:"name"`
+	Ref  string `toml:"ref"`
+}
+
 // Config holds all site generation and server settings.
 type Config struct {
 	Title          string `toml:"title"`
@@ -32,8 +51,14 @@ type Config struct {
 	Favicon        string `toml:"favicon"`
 
 	NavLinks []NavLink `toml:"nav_links"`
+	Aliases  []Alias   `toml:"aliases"`
+	Branches []Branch  `toml:"branches"`
+	Compare  []string  `toml:"compare"`
+	Mailmap  string    `toml:"mailmap"`
 
 	PortsPath string `toml:"ports_path"`
+	PortsURL  string `toml:"-"`
+	Rev       string `toml:"rev"`
 	OutDir    string `toml:"out_dir"`
 	CacheDir  string `toml:"cache_dir"`
 	AssetsDir string `toml:"assets_dir"`
@@ -45,6 +70,114 @@ type Config struct {
 		CIStatus string `toml:"ci_status"`
 	} `toml:"metadata"`
 
+	// CI lists the sources of build results, merged in order. When empty,
+	// ci_status is read as a single JSON source.
+	CI struct {
+		Merge   string     `toml:"merge"`
+		Sources []CISource `toml:"sources"`
+
+		// Vocabulary describes how statuses are shown and counted. It
+		// extends the built-in success, failed, broken, timeout, queued,
+		// building, skipped, cancelled and dependency-failed.
+		Vocabulary CIVocabulary `toml:"vocabulary"`
+
+		// History keeps every snapshot under cache_dir/ci_history. A port
+		// whose status flipped FlakyFlips times within its last
+		// FlakyWindow results is flagged flaky.
+		History     bool `toml:"history"`
+		FlakyWindow int  `toml:"flaky_window"`
+		FlakyFlips  int  `toml:"flaky_flips"`
+
+		// Regressions flags ports whose latest successful build took longer
+		// or produced a larger package than the median of the Window
+		// successful builds before it, by more than Duration or Size
+		// percent. Builds under MinDuration seconds or packages under
+		// MinSize bytes are never flagged.
+		Regressions struct {
+			Enabled     bool    `toml:"enabled"`
+			Window      int     `toml:"window"`
+			Duration    float64 `toml:"duration"`
+			Size        float64 `toml:"size"`
+			MinDuration int64   `toml:"min_duration"`
+			MinSize     int64   `toml:"min_size"`
+		} `toml:"regressions"`
+
+		// Run configures `portsMaster ci run`.
+		Run struct {
+			Command     string `toml:"command"`
+			Workers     int    `toml:"workers"`
+			Timeout     string `toml:"timeout"`
+			Output      string `toml:"output"`
+			LogDir      string `toml:"log_dir"`
+			ArtifactDir string `toml:"artifact_dir"`
+		} `toml:"run"`
+	} `toml:"ci"`
+
+	// Logs controls reading the build logs of failed ports. Rules are tried
+	// in order; the defaults apply when none are configured.
+	Logs struct {
+		Enabled  bool      `toml:"enabled"`
+		Context  int       `toml:"context"`
+		MaxBytes int64     `toml:"max_bytes"`
+		Rules    []LogRule `toml:"rules"`
+	} `toml:"logs"`
+
+	CommitPages struct {
+		Enabled       bool `toml:"enabled"`
+		RetentionDays int  `toml:"retention_days"`
+		MaxDiffBytes  int  `toml:"max_diff_bytes"`
+		MaxFiles      int  `toml:"max_files"`
+	} `toml:"commit_pages"`
+
+	Files struct {
+		Enabled  bool  `toml:"enabled"`
+		MaxBytes int64 `toml:"max_bytes"`
+	} `toml:"files"`
+
+	// Packages configures indexing the archives found under pkg_root and,
+	// for an http(s) pkg_root, the index file listing its packages.
+	Packages struct {
+		Index       bool   `toml:"index"`
+		RemoteIndex string `toml:"remote_index"`
+	} `toml:"packages"`
+
+	Releases struct {
+		Enabled    bool   `toml:"enabled"`
+		TagPattern string `toml:"tag_pattern"`
+	} `toml:"releases"`
+
+	// Remote applies when ports_path is a git URL cloned into the cache.
+	Remote struct {
+		Branch        string `toml:"branch"`
+		Depth         int    `toml:"depth"`
+		FetchInterval string `toml:"fetch_interval"`
+	} `toml:"remote"`
+
+	Signatures struct {
+		Keyring string `toml:"keyring"`
+	} `toml:"signatures"`
+
+	Conventions struct {
+		Style   string            `toml:"style"`
+		Pattern string            `toml:"pattern"`
+		Types   map[string]string `toml:"types"`
+	} `toml:"conventions"`
+
+	// API configures the endpoints of the built-in server, which are only
+	// enabled with a token. The token is kept out of the page hashes.
+	API struct {
+		Token string `toml:"token" json:"-"`
+	} `toml:"api"`
+
+	// Repo configures `portsMaster repo index`, which writes the package
+	// repository index into pkg_root, or into output when set. Arch is the
+	// architecture of the packages, the host's by default.
+	Repo struct {
+		Arch       string `toml:"arch"`
+		SigningKey string `toml:"signing_key" json:"-"`
+		Output     string `toml:"output"`
+	} `toml:"repo"`
+
 	Port           int    `toml:"port"`
 	ServeAddr      string `toml:"serve_addr"`
 	Verbose        bool   `toml:"verbose"`
@@ -52,14 +185,36 @@ type Config struct {
 	Serve          bool   `toml:"serve"`
 	PackageManager string `toml:"package_manager"`
 
+	// Output options of the changelog command; command line only.
+	Format string `toml:"-"`
+	Output string `toml:"-"`
+
 	ExtraCSS []string `toml:"extra_css"`
 	ExtraJS  []string `toml:"extra_js"`
 	Fortunes string   `toml:"fortunes"`
 }
 
+// CISource configures one CI adapter: "json" (category/name → result map),
+// "junit" (directory of JUnit XML reports), "text" (one "category/name
+// status key=value..." line per port) or "jsonpath" (arbitrary JSON mapped
+// through Items and Fields). Statuses renames the statuses a source reports.
+type CISource struct {
+	Type     string            `toml:"type"`
+	Path     string            `toml:"path"`
+	Items    string            `toml:"items"`
+	Fields   map[string]string `toml:"fields"`
+	Statuses map[string]string `toml:"statuses"`
+}
+
+// LogRule classifies a failed build whose log matches Pattern.
+type LogRule struct {
+	Category string `toml:"category"`
+	Pattern  string `toml:"pattern"`
+}
+
 // New returns a configuration with sensible defaults.
 func New() *Config {
-	return &Config{
+	c := &Config{
 		Title:          "portsMaster",
 		Description:    "Universal package repository generator",
 		FooterText:     "Powered by portsMaster",
@@ -70,6 +225,28 @@ func New() *Config {
 		AssetsDir:      "assets",
 		PackageManager: "spc",
 	}
+	c.CommitPages.Enabled = true
+	c.CommitPages.RetentionDays = 365
+	c.CommitPages.MaxDiffBytes = 256 * 1024
+	c.CommitPages.MaxFiles = 100
+	c.Files.Enabled = true
+	c.Packages.Index = true
+	c.Packages.RemoteIndex = "index.json"
+	c.CI.History = true
+	c.Logs.Enabled = true
+	c.Logs.Context = 5
+	c.Logs.MaxBytes = 4 * 1024 * 1024
+	c.CI.FlakyWindow = 10
+	c.CI.FlakyFlips = 3
+	c.CI.Regressions.Enabled = true
+	c.CI.Regressions.Window = 10
+	c.CI.Regressions.Duration = 50
+	c.CI.Regressions.Size = 20
+	c.CI.Regressions.MinDuration = 60
+	c.CI.Regressions.MinSize = 1024 * 1024
+	c.Files.MaxBytes = 256 * 1024
+	c.Conventions.Style = "ports"
+	return c
 }
 
 // LoadFile parses a TOML configuration file.
@@ -98,11 +275,14 @@ func (c *Config) ParseFlags(args []string) (string, error) {
 	desc := fs.String("description", "", "Site description")
 	out := fs.String("out", "", "Output directory")
 	ports := fs.String("ports", "", "Ports tree directory")
+	rev := fs.String("rev", "", "Build the ports tree as of this git revision (commit, tag or branch)")
 	ci := fs.String("ci-status", "", "Path to ci_status.json")
 	verbose := fs.Bool("verbose", false, "Enable verbose logging")
 	watch := fs.Bool("watch", false, "Watch for changes and rebuild")
 	serve := fs.Bool("serve", false, "Start a local web server")
 	port := fs.Int("port", 0, "Server port")
+	format := fs.String("format", "", "Changelog output format: md, html or json")
+	output := fs.String("output", "", "Output of changelog (file), ci run (file) or repo index (directory)")
 
 	if err := fs.Parse(args); err != nil {
 		return "", err
@@ -130,6 +310,9 @@ func (c *Config) ParseFlags(args []string) (string, error) {
 	if isSet("ports") {
 		c.PortsPath = *ports
 	}
+	if isSet("rev") {
+		c.Rev = *rev
+	}
 	if isSet("ci-status") {
 		c.Metadata.CIStatus = *ci
 	}
@@ -145,6 +328,12 @@ func (c *Config) ParseFlags(args []string) (string, error) {
 	if isSet("port") {
 		c.Port = *port
 	}
+	if isSet("format") {
+		c.Format = *format
+	}
+	if isSet("output") {
+		c.Output = *output
+	}
 
 	return configPath, nil
 }
@@ -176,13 +365,95 @@ func (c *Config) Finalize() {
 	}
 
 	c.PortsPath = expand(c.PortsPath)
+	c.Mailmap = expand(c.Mailmap)
+	c.Signatures.Keyring = expand(c.Signatures.Keyring)
 	c.Metadata.PkgsPath = expand(c.Metadata.PkgsPath)
 	c.Metadata.LogsPath = expand(c.Metadata.LogsPath)
 	c.Metadata.CIStatus = expand(c.Metadata.CIStatus)
 	c.CIStatus = expand(c.CIStatus)
+	c.CI.Run.Output = expand(c.CI.Run.Output)
+	c.CI.Run.LogDir = expand(c.CI.Run.LogDir)
+	c.CI.Run.ArtifactDir = expand(c.CI.Run.ArtifactDir)
+	c.Repo.SigningKey = expand(c.Repo.SigningKey)
+	c.Repo.Output = expand(c.Repo.Output)
+	for i := range c.CI.Sources {
+		c.CI.Sources[i].Path = expand(c.CI.Sources[i].Path)
+	}
+	c.finalizeVocabulary()
+
+	if c.API.Token == "" {
+		c.API.Token = os.Getenv("PORTSMASTER_API_TOKEN")
+	}
+
 	c.OutDir = expand(c.OutDir)
 	c.CacheDir = expand(c.CacheDir)
 	c.AssetsDir = expand(c.AssetsDir)
+
+	// A pinned build gets an output directory of its own, so it never
+	// overwrites pages the regular build's manifest still vouches for.
+	if c.Rev != "" {
+		c.OutDir = filepath.Join(c.OutDir, "rev", util.Slugify(c.Rev))
+	}
+
+	if IsGitURL(c.PortsPath) {
+		c.PortsURL = c.PortsPath
+		c.PortsPath = filepath.Join(c.CacheDir, "repos", repoDirName(c.PortsURL))
+	}
+}
+
+// IsGitURL reports whether path names a git remote rather than a local
+// checkout: a URL with a git-capable scheme or an scp-like "user@host:path".
+func IsGitURL(path string) bool {
+	for _, scheme := range []string{"file://", "git://", "ssh://", "git+ssh://", "http://", "https://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	at, colon := strings.Index(path, "@"), strings.Index(path, ":")
+	return at > 0 && colon > at && !strings.Contains(path[:colon], "/")
+}
+
+// repoDirName turns a repository URL into a directory name.
+func repoDirName(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	}
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' {
+			return r
+		}
+		return '-'
+	}, strings.TrimSuffix(url, ".git")), "-.")
+}
+
+// FetchEvery returns how often watch mode fetches a remote ports tree.
+func (c *Config) FetchEvery() time.Duration {
+	if d, err := time.ParseDuration(c.Remote.FetchInterval); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Minute
+}
+
+// CommitPageCutoff returns the date before which commits get no static page.
+// A zero time means every commit is kept.
+func (c *Config) CommitPageCutoff() time.Time {
+	if c.CommitPages.RetentionDays <= 0 {
+		return time.Time{}
+	}
+	return time.Now().AddDate(0, 0, -c.CommitPages.RetentionDays)
+}
+
+// Comparisons returns the branch pairs to compare as "from...to" strings.
+// Without an explicit list, the first branch is compared to each other one.
+func (c *Config) Comparisons() []string {
+	if len(c.Compare) > 0 {
+		return c.Compare
+	}
+	var out []string
+	for i := 1; i < len(c.Branches); i++ {
+		out = append(out, c.Branches[0].Name+"..."+c.Branches[i].Name)
+	}
+	return out
 }
 
 // AssetURL returns a path relative to the site root for the given asset.
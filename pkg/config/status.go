@@ -0,0 +1,113 @@
+package config
+
+import "sort"
+
+// Kinds of CI statuses, which decide how a status is counted: successes
+// and failures make up the pass rate, pending builds (queued or running)
+// have no result yet and neutral ones were not built at all.
+const (
+	CIKindSuccess = "success"
+	CIKindFailure = "failure"
+	CIKindPending = "pending"
+	CIKindNeutral = "neutral"
+)
+
+// CIStatus describes how a CI status is shown and counted. Color is any
+// CSS color and Icon a short text shown before the label.
+type CIStatus struct {
+	Name  string `toml:"-" json:"name"`
+	Label string `toml:"label" json:"label"`
+	Color string `toml:"color" json:"color"`
+	Icon  string `toml:"icon" json:"icon"`
+	Kind  string `toml:"kind" json:"kind"`
+}
+
+// CIVocabulary maps status names to their description.
+type CIVocabulary map[string]CIStatus
+
+// defaultCIVocabulary is the built-in vocabulary, which [ci.vocabulary]
+// entries extend or override field by field.
+var defaultCIVocabulary = CIVocabulary{
+	"success":           {Label: "Success", Color: "var(--status-ok)", Icon: "✓", Kind: CIKindSuccess},
+	"failed":            {Label: "Failed", Color: "var(--status-err)", Icon: "✗", Kind: CIKindFailure},
+	"broken":            {Label: "Broken", Color: "var(--status-err)", Icon: "✗", Kind: CIKindFailure},
+	"timeout":           {Label: "Timeout", Color: "#e8590c", Icon: "⧖", Kind: CIKindFailure},
+	"queued":            {Label: "Queued", Color: "#4dabf7", Icon: "…", Kind: CIKindPending},
+	"building":          {Label: "Building", Color: "#fab005", Icon: "↻", Kind: CIKindPending},
+	"skipped":           {Label: "Skipped", Color: "#adb5bd", Icon: "–", Kind: CIKindNeutral},
+	"cancelled":         {Label: "Cancelled", Color: "#adb5bd", Icon: "⊘", Kind: CIKindNeutral},
+	"dependency-failed": {Label: "Dependency Failed", Color: "#f08c00", Icon: "⇣", Kind: CIKindNeutral},
+}
+
+// Lookup returns the description of a status. Statuses outside the
+// vocabulary are neutral and shown in orange under their own name.
+func (v CIVocabulary) Lookup(name string) CIStatus {
+	if s, ok := v[name]; ok {
+		return s
+	}
+	return CIStatus{Name: name, Label: name, Color: "orange", Icon: "?", Kind: CIKindNeutral}
+}
+
+// Passing reports whether a status counts as a successful build.
+func (v CIVocabulary) Passing(name string) bool {
+	return v.Lookup(name).Kind == CIKindSuccess
+}
+
+// Failing reports whether a status counts as a failed build.
+func (v CIVocabulary) Failing(name string) bool {
+	return v.Lookup(name).Kind == CIKindFailure
+}
+
+// Sorted returns the statuses by kind, in the order successes, failures,
+// pending and neutral, then by name.
+func (v CIVocabulary) Sorted() []CIStatus {
+	order := map[string]int{CIKindSuccess: 0, CIKindFailure: 1, CIKindPending: 2, CIKindNeutral: 3}
+	out := make([]CIStatus, 0, len(v))
+	for _, s := range v {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if order[out[i].Kind] != order[out[j].Kind] {
+			return order[out[i].Kind] < order[out[j].Kind]
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// finalizeVocabulary fills the configured vocabulary in from the built-in
+// one. Unknown kinds are treated as neutral.
+func (c *Config) finalizeVocabulary() {
+	v := make(CIVocabulary, len(defaultCIVocabulary)+len(c.CI.Vocabulary))
+	for name, s := range defaultCIVocabulary {
+		v[name] = s
+	}
+	for name, s := range c.CI.Vocabulary {
+		def, ok := v[name]
+		if !ok {
+			def = CIStatus{Label: name, Color: "orange", Icon: "?", Kind: CIKindNeutral}
+		}
+		if s.Label == "" {
+			s.Label = def.Label
+		}
+		if s.Color == "" {
+			s.Color = def.Color
+		}
+		if s.Icon == "" {
+			s.Icon = def.Icon
+		}
+		switch s.Kind {
+		case CIKindSuccess, CIKindFailure, CIKindPending, CIKindNeutral:
+		case "":
+			s.Kind = def.Kind
+		default:
+			s.Kind = CIKindNeutral
+		}
+		v[name] = s
+	}
+	for name, s := range v {
+		s.Name = name
+		v[name] = s
+	}
+	c.CI.Vocabulary = v
+}
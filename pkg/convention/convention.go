@@ -0,0 +1,152 @@
+package convention
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+)
+
+var (
+	portsRegex        = regexp.MustCompile(`^([A-Za-z0-9][\w.+-]*(?:/[\w.+-]+)?): (.+)$`)
+	conventionalRegex = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?!?: (.+)$`)
+)
+
+// defaultTypes maps Conventional Commits types to commit kinds.
+var defaultTypes = map[string]string{
+	"feat":     model.CommitNew,
+	"new":      model.CommitNew,
+	"update":   model.CommitUpdate,
+	"bump":     model.CommitUpdate,
+	"upgrade":  model.CommitUpdate,
+	"fix":      model.CommitFix,
+	"revert":   model.CommitFix,
+	"remove":   model.CommitRemoval,
+	"build":    model.CommitInfra,
+	"chore":    model.CommitInfra,
+	"ci":       model.CommitInfra,
+	"docs":     model.CommitInfra,
+	"perf":     model.CommitInfra,
+	"refactor": model.CommitInfra,
+	"style":    model.CommitInfra,
+	"test":     model.CommitInfra,
+}
+
+// Checker classifies commits and flags subjects that break the configured
+// convention.
+type Checker struct {
+	style   string
+	pattern *regexp.Regexp
+	types   map[string]string
+}
+
+// New builds a checker for the "ports" (category/port: message),
+// "conventional" or "regex" style. The regex style expects a pattern with
+// optional named groups "type", "scope" and "subject".
+func New(cfg *config.Config) (*Checker, error) {
+	c := &Checker{style: cfg.Conventions.Style, types: make(map[string]string)}
+	for k, v := range defaultTypes {
+		c.types[k] = v
+	}
+	for k, v := range cfg.Conventions.Types {
+		c.types[strings.ToLower(k)] = v
+	}
+
+	switch c.style {
+	case "", "ports":
+		c.style = "ports"
+	case "conventional":
+	case "regex":
+		re, err := regexp.Compile(cfg.Conventions.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit pattern: %w", err)
+		}
+		c.pattern = re
+	default:
+		return nil, fmt.Errorf("unknown commit convention %q", c.style)
+	}
+	return c, nil
+}
+
+// Classify sets the kind of mc and, when its subject breaks the
+// convention, the reason.
+func (c *Checker) Classify(mc *model.Commit) {
+	mc.Type, mc.Violation = model.CommitInfra, ""
+	if mc.IsMerge {
+		return
+	}
+	subject := strings.TrimSpace(strings.SplitN(mc.Message, "\n", 2)[0])
+
+	switch c.style {
+	case "ports":
+		m := portsRegex.FindStringSubmatch(subject)
+		if m == nil {
+			mc.Violation = `subject is not "category/port: message"`
+			return
+		}
+		if strings.Contains(m[1], "/") {
+			mc.Type = classifySubject(m[2])
+		}
+	case "conventional":
+		m := conventionalRegex.FindStringSubmatch(subject)
+		if m == nil {
+			mc.Violation = `subject is not "type(scope): message"`
+			return
+		}
+		c.applyType(mc, m[1])
+	case "regex":
+		m := c.pattern.FindStringSubmatch(subject)
+		if m == nil {
+			mc.Violation = "subject does not match " + c.pattern.String()
+			return
+		}
+		group := func(name string) string {
+			if i := c.pattern.SubexpIndex(name); i > 0 {
+				return m[i]
+			}
+			return ""
+		}
+		switch {
+		case group("type") != "":
+			c.applyType(mc, group("type"))
+		case strings.Contains(group("scope"), "/"):
+			mc.Type = classifySubject(group("subject"))
+		}
+	}
+}
+
+func (c *Checker) applyType(mc *model.Commit, typ string) {
+	if kind, ok := c.types[strings.ToLower(typ)]; ok {
+		mc.Type = kind
+	} else {
+		mc.Violation = fmt.Sprintf("unknown commit type %q", typ)
+	}
+}
+
+// classifySubject guesses the kind of a port commit from the wording of
+// its message, e.g. "update to 1.2", "new port" or "fix build with gcc 15".
+func classifySubject(msg string) string {
+	s := strings.ToLower(msg)
+	first, _, _ := strings.Cut(s, " ")
+	first = strings.TrimRight(first, ",.:;!")
+	switch first {
+	case "new", "add", "import", "initial":
+		return model.CommitNew
+	case "remove", "drop", "delete":
+		return model.CommitRemoval
+	case "update", "bump", "upgrade":
+		return model.CommitUpdate
+	case "fix", "repair":
+		return model.CommitFix
+	}
+	switch {
+	case strings.Contains(s, "new port"):
+		return model.CommitNew
+	case strings.Contains(s, "fix"):
+		return model.CommitFix
+	default:
+		return model.CommitUpdate
+	}
+}
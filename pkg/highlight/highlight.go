@@ -0,0 +1,192 @@
+package highlight
+
+import (
+	"path"
+	"strings"
+)
+
+// Token is a run of text sharing one highlighting class; plain text has an
+// empty class.
+type Token struct {
+	Class string
+	Text  string
+}
+
+// Line is one source line split into tokens.
+type Line []Token
+
+// Token classes.
+const (
+	Comment  = "hl-comment"
+	String   = "hl-string"
+	Variable = "hl-var"
+	Keyword  = "hl-keyword"
+	Key      = "hl-key"
+	Added    = "hl-add"
+	Deleted  = "hl-del"
+	Hunk     = "hl-hunk"
+	Header   = "hl-header"
+)
+
+// File splits content into highlighted lines, picking the lexer from the
+// file name: patches and diffs, port metadata (info, deps) and shell
+// scripts. Anything else is returned as plain text.
+func File(name, content string) []Line {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	base := path.Base(name)
+	switch {
+	case strings.HasSuffix(base, ".patch") || strings.HasSuffix(base, ".diff"):
+		return patch(lines)
+	case base == "info" || base == "deps":
+		return metadata(lines)
+	case strings.HasSuffix(base, ".sh") || strings.HasPrefix(content, "#!"):
+		return shell(lines)
+	}
+	out := make([]Line, len(lines))
+	for i, l := range lines {
+		out[i] = Line{{Text: l}}
+	}
+	return out
+}
+
+func patch(lines []string) []Line {
+	out := make([]Line, len(lines))
+	for i, l := range lines {
+		class := ""
+		switch {
+		case strings.HasPrefix(l, "+++ ") || strings.HasPrefix(l, "--- ") || strings.HasPrefix(l, "diff "):
+			class = Header
+		case strings.HasPrefix(l, "@@"):
+			class = Hunk
+		case strings.HasPrefix(l, "+"):
+			class = Added
+		case strings.HasPrefix(l, "-"):
+			class = Deleted
+		}
+		out[i] = Line{{Class: class, Text: l}}
+	}
+	return out
+}
+
+// metadata highlights "key: value" lines and # comments.
+func metadata(lines []string) []Line {
+	out := make([]Line, len(lines))
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "#") {
+			out[i] = Line{{Class: Comment, Text: l}}
+			continue
+		}
+		if k, v, ok := strings.Cut(l, ":"); ok && k != "" && !strings.ContainsAny(k, " \t") {
+			out[i] = Line{{Class: Key, Text: k + ":"}, {Text: v}}
+			continue
+		}
+		out[i] = Line{{Text: l}}
+	}
+	return out
+}
+
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"for": true, "while": true, "until": true, "do": true, "done": true,
+	"case": true, "esac": true, "in": true, "function": true, "return": true,
+	"local": true, "export": true, "set": true, "unset": true, "exit": true,
+	"cd": true, "shift": true, "break": true, "continue": true, "readonly": true,
+}
+
+// shell highlights comments, quoted strings, variables and keywords. Quotes
+// may span lines, so the open quote is carried over.
+func shell(lines []string) []Line {
+	out := make([]Line, len(lines))
+	var quote byte
+	for i, l := range lines {
+		out[i], quote = shellLine(l, quote)
+	}
+	return out
+}
+
+func shellLine(l string, quote byte) (Line, byte) {
+	var line Line
+	emit := func(class, text string) {
+		if text == "" {
+			return
+		}
+		if n := len(line); n > 0 && line[n-1].Class == class {
+			line[n-1].Text += text
+			return
+		}
+		line = append(line, Token{Class: class, Text: text})
+	}
+
+	i := 0
+	for i < len(l) {
+		if quote != 0 {
+			j := i
+			for j < len(l) && l[j] != quote {
+				if l[j] == '\\' && quote == '"' {
+					j++
+				}
+				j++
+			}
+			if j >= len(l) {
+				emit(String, l[i:])
+				return line, quote
+			}
+			emit(String, l[i:j+1])
+			i, quote = j+1, 0
+			continue
+		}
+
+		c := l[i]
+		switch {
+		case c == '#' && (i == 0 || l[i-1] == ' ' || l[i-1] == '\t' || l[i-1] == ';'):
+			emit(Comment, l[i:])
+			return line, 0
+		case c == '\'' || c == '"':
+			quote = c
+			emit(String, l[i:i+1])
+			i++
+		case c == '\\' && i+1 < len(l):
+			emit("", l[i:i+2])
+			i += 2
+		case c == '$':
+			j := i + 1
+			if j < len(l) && l[j] == '{' {
+				if k := strings.IndexByte(l[j:], '}'); k >= 0 {
+					j += k + 1
+				} else {
+					j = len(l)
+				}
+			} else {
+				for j < len(l) && isWord(l[j]) {
+					j++
+				}
+			}
+			if j == i+1 {
+				emit("", "$")
+			} else {
+				emit(Variable, l[i:j])
+			}
+			i = j
+		case isWord(c):
+			j := i
+			for j < len(l) && isWord(l[j]) {
+				j++
+			}
+			word := l[i:j]
+			if shellKeywords[word] && (i == 0 || !isWord(l[i-1])) {
+				emit(Keyword, word)
+			} else {
+				emit("", word)
+			}
+			i = j
+		default:
+			emit("", l[i:i+1])
+			i++
+		}
+	}
+	return line, quote
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -21,6 +21,128 @@ type PackageInfo struct {
 	Filename string `cbor:"filename" json:"filename"`
 	Path     string `cbor:"path" json:"path"`
 	Size     int64  `cbor:"size" json:"size"`
+	// URL is the download location of a package on a remote package root.
+	URL string `cbor:"url,omitempty" json:"url,omitempty"`
+	// Files lists the archive contents when packages are indexed.
+	Files []PackageEntry `cbor:"files,omitempty" json:"files,omitempty"`
+}
+
+// PackageEntry is one member of a package archive. Path is absolute, as
+// installed; Type is "file", "dir" or "link", with Link the symlink target.
+type PackageEntry struct {
+	Path string   `cbor:"path" json:"path"`
+	Size int64    `cbor:"size,omitempty" json:"size,omitempty"`
+	Type string   `cbor:"type" json:"type"`
+	Link string   `cbor:"link,omitempty" json:"link,omitempty"`
+	ELF  *ELFInfo `cbor:"elf,omitempty" json:"elf,omitempty"`
+}
+
+// ELFInfo describes an ELF file of a package. Kind is "executable",
+// "library" or "object"; Soname and Needed come from the dynamic section.
+// The hardening fields are set for executables and libraries only: RELRO
+// is "", "partial" or "full", StackProtector and Fortify tell whether the
+// file calls __stack_chk_fail or any fortified *_chk function.
+type ELFInfo struct {
+	Kind           string   `cbor:"kind" json:"kind"`
+	Soname         string   `cbor:"soname,omitempty" json:"soname,omitempty"`
+	Needed         []string `cbor:"needed,omitempty" json:"needed,omitempty"`
+	PIE            bool     `cbor:"pie,omitempty" json:"pie,omitempty"`
+	RELRO          string   `cbor:"relro,omitempty" json:"relro,omitempty"`
+	NXStack        bool     `cbor:"nx_stack,omitempty" json:"nx_stack,omitempty"`
+	StackProtector bool     `cbor:"stack_protector,omitempty" json:"stack_protector,omitempty"`
+	Fortify        bool     `cbor:"fortify,omitempty" json:"fortify,omitempty"`
+}
+
+// Hardened reports whether the hardening fields apply to the file.
+func (e *ELFInfo) Hardened() bool {
+	return e.Kind == "executable" || e.Kind == "library"
+}
+
+// HardeningSummary counts the executables and libraries of one or more
+// ports and how many of them have each hardening feature. PIE only applies
+// to executables.
+type HardeningSummary struct {
+	Binaries       int `cbor:"binaries" json:"binaries"`
+	Executables    int `cbor:"executables" json:"executables"`
+	PIE            int `cbor:"pie" json:"pie"`
+	PartialRELRO   int `cbor:"partial_relro" json:"partial_relro"`
+	FullRELRO      int `cbor:"full_relro" json:"full_relro"`
+	NXStack        int `cbor:"nx_stack" json:"nx_stack"`
+	StackProtector int `cbor:"stack_protector" json:"stack_protector"`
+	Fortify        int `cbor:"fortify" json:"fortify"`
+}
+
+// Add counts one ELF file.
+func (s *HardeningSummary) Add(e *ELFInfo) {
+	s.Binaries++
+	if e.Kind == "executable" {
+		s.Executables++
+		if e.PIE {
+			s.PIE++
+		}
+	}
+	switch e.RELRO {
+	case "partial":
+		s.PartialRELRO++
+	case "full":
+		s.FullRELRO++
+	}
+	if e.NXStack {
+		s.NXStack++
+	}
+	if e.StackProtector {
+		s.StackProtector++
+	}
+	if e.Fortify {
+		s.Fortify++
+	}
+}
+
+// Merge adds the counts of another summary.
+func (s *HardeningSummary) Merge(o HardeningSummary) {
+	s.Binaries += o.Binaries
+	s.Executables += o.Executables
+	s.PIE += o.PIE
+	s.PartialRELRO += o.PartialRELRO
+	s.FullRELRO += o.FullRELRO
+	s.NXStack += o.NXStack
+	s.StackProtector += o.StackProtector
+	s.Fortify += o.Fortify
+}
+
+// Coverage is the share of the applicable checks that pass, in percent:
+// PIE for executables, full RELRO, NX stack and the stack protector for
+// every binary. FORTIFY is left out as not every binary calls a function
+// that has a fortified variant.
+func (s HardeningSummary) Coverage() float64 {
+	checks := s.Executables + 3*s.Binaries
+	if checks == 0 {
+		return 100
+	}
+	return float64(s.PIE+s.FullRELRO+s.NXStack+s.StackProtector) / float64(checks) * 100
+}
+
+// HardeningSnapshot is the tree-wide hardening summary at a point in time.
+type HardeningSnapshot struct {
+	Time int64 `json:"time"`
+	HardeningSummary
+}
+
+// LinkCheck compares the libraries a port's ELF files need with its link
+// dependencies. Missing lists needed libraries of ports that are not link
+// dependencies, Unneeded link dependencies whose libraries nothing needs
+// and Unresolved needed libraries no port provides.
+type LinkCheck struct {
+	Sonames    []string     `cbor:"sonames,omitempty" json:"sonames,omitempty"`
+	Missing    []SonameLink `cbor:"missing,omitempty" json:"missing,omitempty"`
+	Unneeded   []string     `cbor:"unneeded,omitempty" json:"unneeded,omitempty"`
+	Unresolved []string     `cbor:"unresolved,omitempty" json:"unresolved,omitempty"`
+}
+
+// SonameLink is a needed library and the port providing it.
+type SonameLink struct {
+	Soname string `cbor:"soname" json:"soname"`
+	Port   string `cbor:"port" json:"port"`
 }
 
 type Port struct {
@@ -43,6 +165,56 @@ type Port struct {
 	IsUnmaintained bool          `cbor:"is_unmaintained" json:"is_unmaintained"`
 	CI             *CIInfo       `cbor:"ci,omitempty" json:"ci,omitempty"`
 	RecipeLines    int           `cbor:"recipe_lines" json:"recipe_lines"`
+
+	// CI history: recent records oldest first, the start of the current
+	// failure streak (Unix time, 0 when passing) and the number of status
+	// flips within the flaky window.
+	CIHistory   []CIRecord `cbor:"ci_history,omitempty" json:"ci_history,omitempty"`
+	BrokenSince int64      `cbor:"broken_since,omitempty" json:"broken_since,omitempty"`
+	CIFlips     int        `cbor:"ci_flips,omitempty" json:"ci_flips,omitempty"`
+	Flaky       bool       `cbor:"flaky,omitempty" json:"flaky,omitempty"`
+	// Regressions lists the metrics of the latest successful build that
+	// grew beyond the configured thresholds.
+	Regressions []Regression `cbor:"regressions,omitempty" json:"regressions,omitempty"`
+
+	// Failure is the failing step found in the build log of a failed build.
+	Failure *BuildFailure `cbor:"failure,omitempty" json:"failure,omitempty"`
+
+	// Links is the shared-library check of the port's indexed packages,
+	// with Sonames the libraries they provide.
+	Links *LinkCheck `cbor:"links,omitempty" json:"links,omitempty"`
+	// Hardening summarizes the hardening of the executables and libraries
+	// of the port's indexed packages.
+	Hardening *HardeningSummary `cbor:"hardening,omitempty" json:"hardening,omitempty"`
+}
+
+// HasContents reports whether the contents of any package of the port
+// were indexed.
+func (p *Port) HasContents() bool {
+	for _, pkg := range p.Packages {
+		if len(pkg.Files) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildFailure is the failing step of a build log: the category of the
+// rule that matched, the matching line and the lines around it.
+type BuildFailure struct {
+	Category     string   `cbor:"category" json:"category"`
+	Line         int      `cbor:"line,omitempty" json:"line,omitempty"`
+	ExcerptStart int      `cbor:"excerpt_start" json:"excerpt_start"`
+	Excerpt      []string `cbor:"excerpt" json:"excerpt"`
+	// Partial is set when only the tail of the log was read, so line
+	// numbers do not count from the start of the log.
+	Partial bool `cbor:"partial,omitempty" json:"partial,omitempty"`
+}
+
+// FailureReason counts failed ports per failure category.
+type FailureReason struct {
+	Category string
+	Count    int
 }
 
 type CIInfo struct {
@@ -57,12 +229,61 @@ type CIInfo struct {
 	DepsInstalledSize int64  `json:"deps_installed_size"`
 }
 
+// CIRecord is one build result of a port as observed in a CI snapshot.
+type CIRecord struct {
+	Seen      int64  `cbor:"seen" json:"seen"`
+	Status    string `cbor:"status" json:"status"`
+	Started   int64  `cbor:"started,omitempty" json:"started,omitempty"`
+	Duration  int64  `cbor:"duration,omitempty" json:"duration,omitempty"`
+	Size      int64  `cbor:"size,omitempty" json:"size,omitempty"`
+	Installed int64  `cbor:"installed,omitempty" json:"installed,omitempty"`
+}
+
+// Time is when the build ran, or when it was first seen if unknown.
+func (r CIRecord) Time() int64 {
+	if r.Started > 0 {
+		return r.Started
+	}
+	return r.Seen
+}
+
+// Regression is a build metric ("duration", "size" or "installed_size")
+// that grew past its threshold: the median of the earlier successful
+// builds, the latest value and every recorded value, oldest first.
+type Regression struct {
+	Metric   string  `cbor:"metric" json:"metric"`
+	Baseline int64   `cbor:"baseline" json:"baseline"`
+	Current  int64   `cbor:"current" json:"current"`
+	Series   []int64 `cbor:"series,omitempty" json:"series,omitempty"`
+}
+
+// Change is the growth over the baseline in percent.
+func (r Regression) Change() float64 {
+	if r.Baseline == 0 {
+		return 0
+	}
+	return float64(r.Current-r.Baseline) / float64(r.Baseline) * 100
+}
+
+// CITransition is a change of a port's CI status between two records.
+type CITransition struct {
+	Port *Port
+	From string
+	To   string
+	At   int64
+}
+
 type Category struct {
 	Name        string  `cbor:"name" json:"name"`
 	Description string  `cbor:"description" json:"description"`
 	Ports       []*Port `cbor:"ports,omitempty" json:"ports,omitempty"`
 }
 
+type Person struct {
+	Name  string `cbor:"name" json:"name"`
+	Email string `cbor:"email,omitempty" json:"email,omitempty"`
+}
+
 type Commit struct {
 	Hash          string    `cbor:"hash" json:"hash"`
 	Author        string    `cbor:"author" json:"author"`
@@ -73,27 +294,175 @@ type Commit struct {
 	ModifiedFiles []string  `cbor:"modified_files,omitempty" json:"modified_files,omitempty"`
 	DeletedFiles  []string  `cbor:"deleted_files,omitempty" json:"deleted_files,omitempty"`
 	IsMerge       bool      `cbor:"is_merge" json:"is_merge"`
+	CoAuthors     []Person  `cbor:"co_authors,omitempty" json:"co_authors,omitempty"`
+	ReviewedBy    []Person  `cbor:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	SignedOffBy   []Person  `cbor:"signed_off_by,omitempty" json:"signed_off_by,omitempty"`
+	Fixes         []string  `cbor:"fixes,omitempty" json:"fixes,omitempty"`
+
+	Signature SignatureStatus `cbor:"signature,omitempty" json:"signature,omitempty"`
+	SignedBy  string          `cbor:"signed_by,omitempty" json:"signed_by,omitempty"`
+
+	Type      string `cbor:"type,omitempty" json:"type,omitempty"`
+	Violation string `cbor:"violation,omitempty" json:"violation,omitempty"`
+}
+
+// Commit kinds assigned by the commit convention checker.
+const (
+	CommitUpdate  = "update"
+	CommitNew     = "new"
+	CommitFix     = "fix"
+	CommitRemoval = "removal"
+	CommitInfra   = "infra"
+)
+
+// CommitTypes lists the commit kinds in display order.
+var CommitTypes = []string{CommitUpdate, CommitNew, CommitFix, CommitRemoval, CommitInfra}
+
+// SignatureStatus is the outcome of verifying a commit signature against the
+// configured keyring. It is empty when verification is disabled.
+type SignatureStatus string
+
+const (
+	SignatureValid      SignatureStatus = "valid"
+	SignatureUnknownKey SignatureStatus = "unknown_key"
+	SignatureBad        SignatureStatus = "bad"
+	SignatureUnsigned   SignatureStatus = "unsigned"
+)
+
+// PortFile is a file of a port directory as listed by the file browser.
+type PortFile struct {
+	Path   string
+	Size   int64
+	Binary bool
+	Commit *Commit
+}
+
+// BlameHunk is a run of consecutive lines last changed by the same commit.
+type BlameHunk struct {
+	Commit *Commit  `json:"commit"`
+	Start  int      `json:"start"`
+	Lines  []string `json:"lines"`
+}
+
+// RemovedPort records a port directory that was deleted from the tree,
+// along with the last metadata known before the removal.
+type RemovedPort struct {
+	Category  string  `cbor:"category" json:"category"`
+	Name      string  `cbor:"name" json:"name"`
+	LastHash  string  `cbor:"last_hash" json:"last_hash"`
+	Commit    *Commit `cbor:"commit" json:"commit"`
+	LastKnown *Port   `cbor:"last_known,omitempty" json:"last_known,omitempty"`
+}
+
+type DiffLineKind string
+
+const (
+	DiffContext DiffLineKind = "ctx"
+	DiffAdd     DiffLineKind = "add"
+	DiffDelete  DiffLineKind = "del"
+	DiffHunk    DiffLineKind = "hunk"
+)
+
+type DiffLine struct {
+	Kind DiffLineKind `json:"kind"`
+	Text string       `json:"text"`
+}
+
+// FileDiff is the unified diff of one file within a commit.
+type FileDiff struct {
+	Path      string     `json:"path"`
+	Action    string     `json:"action"`
+	Binary    bool       `json:"binary,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+	Added     int        `json:"added"`
+	Deleted   int        `json:"deleted"`
+	Lines     []DiffLine `json:"lines,omitempty"`
+}
+
+// CommitDiff is the size-limited diff of a commit against its first parent.
+type CommitDiff struct {
+	Files   []*FileDiff `json:"files"`
+	Omitted []string    `json:"omitted,omitempty"`
+}
+
+// VersionChange describes a port whose version differs between two trees.
+type VersionChange struct {
+	From      *Port
+	To        *Port
+	Direction int
+}
+
+// BranchComparison lists the differences between the ports of two refs.
+type BranchComparison struct {
+	From      string
+	To        string
+	Added     []*Port
+	Removed   []*Port
+	Changed   []VersionChange
+	Unchanged int
+}
+
+// ChangelogEntry is one line of release notes. Old and New hold the value
+// that changed (version, license, maintainer) where it applies.
+type ChangelogEntry struct {
+	Category string    `json:"category"`
+	Name     string    `json:"name"`
+	Old      string    `json:"old,omitempty"`
+	New      string    `json:"new,omitempty"`
+	Commits  []*Commit `json:"commits,omitempty"`
+}
+
+// ChangelogSection groups the entries of one kind of change.
+type ChangelogSection struct {
+	Kind    string           `json:"kind"`
+	Title   string           `json:"title"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// Changelog lists what changed in the ports tree between two git refs.
+type Changelog struct {
+	Version  int                `json:"version"`
+	From     string             `json:"from"`
+	To       string             `json:"to"`
+	FromHash string             `json:"from_hash,omitempty"`
+	ToHash   string             `json:"to_hash"`
+	Date     time.Time          `json:"date"`
+	Sections []ChangelogSection `json:"sections"`
 }
 
 type Database struct {
 	Categories       []*Category             `cbor:"categories" json:"categories"`
 	Ports            []*Port                 `cbor:"ports" json:"ports"`
+	RemovedPorts     []*RemovedPort          `cbor:"removed_ports,omitempty" json:"removed_ports,omitempty"`
+	FileCommits      map[string]string       `cbor:"file_commits,omitempty" json:"file_commits,omitempty"`
 	RecentCommits    []*Commit               `cbor:"recent_commits" json:"recent_commits"`
 	ContributorStats map[string]*Contributor `cbor:"contributor_stats" json:"contributor_stats"`
 	GeneratedAt      time.Time               `cbor:"generated_at" json:"generated_at"`
+	// HardeningTrend is the tree-wide hardening summary of past builds,
+	// oldest first.
+	HardeningTrend []HardeningSnapshot `cbor:"hardening_trend,omitempty" json:"hardening_trend,omitempty"`
 }
 
 type SiteData struct {
+	Prefix            string
+	Branch            string
+	Comparisons       []*BranchComparison
+	Releases          []*Changelog
 	Categories        []*Category
 	Ports             []*Port
 	PortMap           map[string]*Port
+	RemovedPorts      []*RemovedPort
 	SimplePortMap     map[string]*Port
 	RecentCommits     []*Commit
+	CommitPages       map[string]*Commit
 	TotalPorts        int
 	BrokenCount       int
 	UnmaintainedCount int
 	UpdatedThisWeek   int
 	NewPortsCount     int
+	UnsignedRecent    int
+	CommitTypeCounts  map[string]int
+	Violations        []*Commit
 	AllAuthors        []string
 	AllTimeframes     []string
 
@@ -101,6 +470,7 @@ type SiteData struct {
 	DailyStats       []DailyStat
 	ContributorStats map[string]*Contributor
 	TopContributors  []*Contributor
+	Profiles         []*ContributorProfile
 	LicenseStats     map[string]int
 	BuildStats       BuildStats
 	MaxDailyCommits  int
@@ -111,23 +481,67 @@ type SiteData struct {
 	Top5LinePercentage float64
 	TotalCommits       int
 	TopSizes           []*Port
+
+	CIBroken       []*Port
+	CIFlaky        []*Port
+	CITransitions  []CITransition
+	CIRegressed    []*Port
+	FailureReasons []FailureReason
+
+	Hardening      HardeningSummary
+	HardeningPorts []*Port
+	HardeningTrend []HardeningSnapshot
 }
 
 type Contributor struct {
-	Name       string   `cbor:"name" json:"name"`
-	Email      string   `cbor:"email" json:"email"`
-	Count      int      `cbor:"count" json:"count"`
-	OtherNames []string `cbor:"other_names" json:"other_names"`
+	Name        string         `cbor:"name" json:"name"`
+	Email       string         `cbor:"email" json:"email"`
+	Slug        string         `cbor:"slug,omitempty" json:"slug,omitempty"`
+	Count       int            `cbor:"count" json:"count"`
+	CoAuthored  int            `cbor:"co_authored,omitempty" json:"co_authored,omitempty"`
+	OtherNames  []string       `cbor:"other_names" json:"other_names"`
+	FirstCommit time.Time      `cbor:"first_commit" json:"first_commit"`
+	LastCommit  time.Time      `cbor:"last_commit" json:"last_commit"`
+	Daily       map[string]int `cbor:"daily,omitempty" json:"daily,omitempty"`
+	Ports       map[string]int `cbor:"ports,omitempty" json:"ports,omitempty"`
+	Signatures  map[string]int `cbor:"signatures,omitempty" json:"signatures,omitempty"`
+}
+
+type PortCount struct {
+	Key   string
+	Count int
+}
+
+// ContributorProfile is the view of a single contributor's page.
+type ContributorProfile struct {
+	*Contributor
+	Maintained []*Port
+	TopPorts   []PortCount
+	Monthly    []DailyStat
+	MaxMonthly int
+	Heatmap    []DailyStat
+	MaxDaily   int
 }
 
+// BuildStats counts ports with a CI result by the kind of their status:
+// Pending builds are queued or running and Neutral ones were not built.
+// ByStatus counts every status.
 type BuildStats struct {
 	Total     int
 	Success   int
 	Failed    int
+	Pending   int
+	Neutral   int
+	ByStatus  map[string]int
 	TotalTime int64
 	AvgTime   int64
 }
 
+// Finished is the number of builds that passed or failed.
+func (b BuildStats) Finished() int {
+	return b.Success + b.Failed
+}
+
 type DailyStat struct {
 	Date    string
 	Count   int
@@ -1,6 +1,9 @@
 package model
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 // Parser defines the interface for parsing a single port's metadata.
 type Parser interface {
@@ -8,12 +11,26 @@ type Parser interface {
 	Type() string
 }
 
+// MetadataParser parses a port's metadata file from an arbitrary reader,
+// such as a blob read from git history.
+type MetadataParser interface {
+	MetadataFile() string
+	ParseMetadata(category, name string, r io.Reader) (*Port, error)
+}
+
 // Scanner defines the interface for discovering categories and ports in a tree.
 type Scanner interface {
 	Scan(ctx context.Context) ([]*Category, []*Port, error)
 	Type() string
 }
 
+// CIProvider loads build results from one CI source, keyed by
+// "category/name".
+type CIProvider interface {
+	Load(ctx context.Context) (map[string]*CIInfo, error)
+	Type() string
+}
+
 // Source combines scanning and enrichment logic.
 type Source interface {
 	Fetch(ctx context.Context) (*Database, error)
@@ -0,0 +1,131 @@
+package pkgindex
+
+import (
+	"bytes"
+	"debug/elf"
+	"io"
+	"strings"
+
+	"portsMaster/pkg/model"
+)
+
+// maxELFBytes bounds the size of the ELF files that are inspected; larger
+// ones are listed without ELF details.
+const maxELFBytes = 256 << 20
+
+var elfMagic = []byte(elf.ELFMAG)
+
+// readELF inspects a regular file of size bytes read from r. It returns nil
+// for files that are not ELF objects or cannot be parsed.
+func readELF(r io.Reader, size int64) *model.ELFInfo {
+	if size < int64(len(elfMagic)) || size > maxELFBytes {
+		return nil
+	}
+	head := make([]byte, len(elfMagic))
+	if _, err := io.ReadFull(r, head); err != nil || !bytes.Equal(head, elfMagic) {
+		return nil
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return nil
+	}
+	f, err := elf.NewFile(bytes.NewReader(append(head, rest...)))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	info := &model.ELFInfo{Kind: elfKind(f)}
+	if sonames, err := f.DynString(elf.DT_SONAME); err == nil && len(sonames) > 0 {
+		info.Soname = sonames[0]
+	}
+	info.Needed, _ = f.DynString(elf.DT_NEEDED)
+	if info.Hardened() {
+		readHardening(f, info)
+	}
+	return info
+}
+
+// elfKind tells executables, position-independent ones included, from
+// shared libraries. Like checksec it trusts DF_1_PIE first, which static-pie
+// binaries set without requesting an interpreter. A shared object with a
+// soname is a library even when it can also be run, as libc.so.6 can;
+// otherwise the program interpreter decides.
+func elfKind(f *elf.File) string {
+	switch f.Type {
+	case elf.ET_EXEC:
+		return "executable"
+	case elf.ET_DYN:
+		if v, err := f.DynValue(elf.DT_FLAGS_1); err == nil && len(v) > 0 && elf.DynFlag1(v[0])&elf.DF_1_PIE != 0 {
+			return "executable"
+		}
+		if sonames, err := f.DynString(elf.DT_SONAME); err == nil && len(sonames) > 0 {
+			return "library"
+		}
+		for _, p := range f.Progs {
+			if p.Type == elf.PT_INTERP {
+				return "executable"
+			}
+		}
+		return "library"
+	}
+	return "object"
+}
+
+// readHardening fills in the hardening features of an executable or
+// library the way checksec finds them: from its program headers, dynamic
+// flags and the stack protector and fortified functions it calls.
+func readHardening(f *elf.File, info *model.ELFInfo) {
+	info.PIE = f.Type == elf.ET_DYN && info.Kind == "executable"
+	// Without a PT_GNU_STACK header the kernel maps the stack executable.
+	for _, p := range f.Progs {
+		switch p.Type {
+		case elf.PT_GNU_RELRO:
+			info.RELRO = "partial"
+		case elf.PT_GNU_STACK:
+			info.NXStack = p.Flags&elf.PF_X == 0
+		}
+	}
+	if info.RELRO != "" && bindNow(f) {
+		info.RELRO = "full"
+	}
+
+	// Dynamic objects call into libc, so only undefined dynamic symbols
+	// count; static binaries carry the functions in their symbol table.
+	var names []string
+	if syms, err := f.DynamicSymbols(); err == nil {
+		for _, s := range syms {
+			if s.Section == elf.SHN_UNDEF {
+				names = append(names, s.Name)
+			}
+		}
+	}
+	if syms, err := f.Symbols(); err == nil && len(names) == 0 {
+		for _, s := range syms {
+			names = append(names, s.Name)
+		}
+	}
+	for _, name := range names {
+		switch {
+		case name == "__stack_chk_fail" || name == "__stack_chk_guard":
+			info.StackProtector = true
+		case strings.HasPrefix(name, "__") && strings.HasSuffix(name, "_chk"):
+			info.Fortify = true
+		}
+	}
+}
+
+// bindNow reports whether the dynamic linker resolves every symbol at
+// load time, which turns RELRO into full RELRO.
+func bindNow(f *elf.File) bool {
+	if v, err := f.DynValue(elf.DT_BIND_NOW); err == nil && len(v) > 0 {
+		return true
+	}
+	if v, err := f.DynValue(elf.DT_FLAGS); err == nil && len(v) > 0 && elf.DynFlag(v[0])&elf.DF_BIND_NOW != 0 {
+		return true
+	}
+	if v, err := f.DynValue(elf.DT_FLAGS_1); err == nil && len(v) > 0 && elf.DynFlag1(v[0])&elf.DF_1_NOW != 0 {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package pkgindex
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"portsMaster/pkg/model"
+)
+
+// CheckHardening sets Port.Hardening for every port whose indexed packages
+// hold executables or libraries, and returns the tree-wide summary.
+func CheckHardening(ports []*model.Port) model.HardeningSummary {
+	var total model.HardeningSummary
+	for _, p := range ports {
+		var s model.HardeningSummary
+		forEachELF(p, func(e *model.ELFInfo) {
+			if e.Hardened() {
+				s.Add(e)
+			}
+		})
+		if s.Binaries > 0 {
+			p.Hardening = &s
+			total.Merge(s)
+		}
+	}
+	return total
+}
+
+// Trend is the record of the tree-wide hardening summary, one JSON line
+// per change.
+type Trend struct {
+	path string
+}
+
+// OpenTrend returns the trend stored at path.
+func OpenTrend(path string) *Trend {
+	return &Trend{path: path}
+}
+
+// Record appends the summary unless it equals the last one recorded.
+func (t *Trend) Record(s model.HardeningSummary, now time.Time) error {
+	snaps, err := t.Load()
+	if err != nil {
+		return err
+	}
+	if n := len(snaps); n > 0 && snaps[n-1].HardeningSummary == s {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	b, _ := json.Marshal(model.HardeningSnapshot{Time: now.Unix(), HardeningSummary: s})
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load returns the recorded summaries, oldest first, skipping lines that
+// cannot be parsed.
+func (t *Trend) Load() ([]model.HardeningSnapshot, error) {
+	f, err := os.Open(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var snaps []model.HardeningSnapshot
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var s model.HardeningSnapshot
+		if json.Unmarshal(sc.Bytes(), &s) == nil {
+			snaps = append(snaps, s)
+		}
+	}
+	return snaps, sc.Err()
+}
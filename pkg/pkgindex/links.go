@@ -0,0 +1,102 @@
+package pkgindex
+
+import (
+	"sort"
+
+	"portsMaster/pkg/model"
+)
+
+// CheckLinks compares, for every port with indexed packages, the shared
+// libraries its ELF files need with its link dependencies, and sets
+// Port.Links. Dependencies resolve by port name, then by provides.
+func CheckLinks(ports []*model.Port) {
+	byName := make(map[string]*model.Port)
+	for _, p := range ports {
+		if _, ok := byName[p.Name]; !ok {
+			byName[p.Name] = p
+		}
+	}
+	for _, p := range ports {
+		for _, prov := range p.Provides {
+			if _, ok := byName[prov]; !ok {
+				byName[prov] = p
+			}
+		}
+	}
+
+	provided := make(map[*model.Port]map[string]bool)
+	providers := make(map[string]*model.Port)
+	for _, p := range ports {
+		sonames := make(map[string]bool)
+		forEachELF(p, func(e *model.ELFInfo) {
+			if e.Soname != "" {
+				sonames[e.Soname] = true
+				if _, ok := providers[e.Soname]; !ok {
+					providers[e.Soname] = p
+				}
+			}
+		})
+		provided[p] = sonames
+	}
+
+	for _, p := range ports {
+		if !p.HasContents() {
+			continue
+		}
+		needed := make(map[string]bool)
+		forEachELF(p, func(e *model.ELFInfo) {
+			for _, so := range e.Needed {
+				if !provided[p][so] {
+					needed[so] = true
+				}
+			}
+		})
+		links := make(map[*model.Port]bool)
+		for _, d := range p.Deps {
+			if dp, ok := byName[d.Name]; ok && d.Type == model.DepLink && dp != p {
+				links[dp] = true
+			}
+		}
+
+		check := &model.LinkCheck{Sonames: sortedKeys(provided[p])}
+		used := make(map[*model.Port]bool)
+		for _, so := range sortedKeys(needed) {
+			dp, ok := providers[so]
+			if !ok {
+				check.Unresolved = append(check.Unresolved, so)
+				continue
+			}
+			used[dp] = true
+			if !links[dp] {
+				check.Missing = append(check.Missing, model.SonameLink{Soname: so, Port: dp.Category + "/" + dp.Name})
+			}
+		}
+		// A dependency without indexed libraries may still be needed.
+		for dp := range links {
+			if len(provided[dp]) > 0 && !used[dp] {
+				check.Unneeded = append(check.Unneeded, dp.Category+"/"+dp.Name)
+			}
+		}
+		sort.Strings(check.Unneeded)
+		p.Links = check
+	}
+}
+
+func forEachELF(p *model.Port, fn func(*model.ELFInfo)) {
+	for _, pkg := range p.Packages {
+		for _, f := range pkg.Files {
+			if f.ELF != nil {
+				fn(f.ELF)
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package pkgindex
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"portsMaster/pkg/cache"
+	"portsMaster/pkg/model"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// cacheVersion is bumped whenever the cached index changes shape.
+const cacheVersion = "4"
+
+type cachedIndex struct {
+	Version string               `json:"version"`
+	Path    string               `json:"path"`
+	Size    int64                `json:"size"`
+	ModTime int64                `json:"mtime"`
+	Files   []model.PackageEntry `json:"files"`
+}
+
+// Indexer indexes the contents of package archives, tarballs compressed
+// with gzip, xz or zstd, keeping the result for every archive under
+// cacheDir/packages until the archive's size or modification time changes.
+type Indexer struct {
+	cacheDir string
+}
+
+// New returns an indexer caching under cacheDir.
+func New(cacheDir string) *Indexer {
+	return &Indexer{cacheDir: cacheDir}
+}
+
+// Index fills in the contents of a package.
+func (ix *Indexer) Index(pkg *model.PackageInfo) error {
+	st, err := os.Stat(pkg.Path)
+	if err != nil {
+		return err
+	}
+
+	cachePath := filepath.Join(ix.cacheDir, "packages", cache.HashString(pkg.Path)+".json")
+	if b, err := os.ReadFile(cachePath); err == nil {
+		var c cachedIndex
+		if json.Unmarshal(b, &c) == nil && c.Version == cacheVersion && c.Path == pkg.Path && c.Size == st.Size() && c.ModTime == st.ModTime().UnixNano() {
+			pkg.Files = c.Files
+			return nil
+		}
+	}
+
+	files, err := List(pkg.Path)
+	if err != nil {
+		return err
+	}
+	pkg.Files = files
+
+	_ = os.MkdirAll(filepath.Dir(cachePath), 0755)
+	if b, err := json.Marshal(cachedIndex{
+		Version: cacheVersion,
+		Path:    pkg.Path,
+		Size:    st.Size(),
+		ModTime: st.ModTime().UnixNano(),
+		Files:   files,
+	}); err == nil {
+		_ = os.WriteFile(cachePath, b, 0644)
+	}
+	return nil
+}
+
+// List returns the members of a package archive in archive order, with
+// the dynamic linking details of ELF files. Device nodes and other special
+// files are left out.
+func List(name string) ([]model.PackageEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := Decompress(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var files []model.PackageEntry
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p := path.Clean("/" + h.Name)
+		if p == "/" {
+			continue
+		}
+		e := model.PackageEntry{Path: p}
+		switch h.Typeflag {
+		case tar.TypeReg:
+			e.Type, e.Size = "file", h.Size
+			e.ELF = readELF(tr, h.Size)
+		case tar.TypeLink:
+			e.Type, e.Link = "file", path.Clean("/"+h.Linkname)
+		case tar.TypeSymlink:
+			e.Type, e.Link = "link", h.Linkname
+		case tar.TypeDir:
+			e.Type = "dir"
+		default:
+			continue
+		}
+		files = append(files, e)
+	}
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress detects gzip, xz and zstd streams by their magic bytes;
+// anything else is returned as is.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(xzMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, xzMagic):
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case bytes.HasPrefix(head, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
@@ -2,6 +2,8 @@ package port
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
 	"portsMaster/pkg/config"
 	"portsMaster/pkg/model"
 	"portsMaster/pkg/port/spc"
@@ -10,10 +12,15 @@ import (
 
 // NewScanner returns a scanner implementation based on the configuration.
 func NewScanner(cfg *config.Config, reg *registry.Registry) (model.Scanner, error) {
+	return NewScannerFS(cfg, reg, os.DirFS(reg.PortsRoot()))
+}
+
+// NewScannerFS returns a scanner reading the ports tree from fsys.
+func NewScannerFS(cfg *config.Config, reg *registry.Registry, fsys fs.FS) (model.Scanner, error) {
 	switch cfg.PackageManager {
 	case "spc":
-		parser := spc.NewParser(reg)
-		return spc.NewScanner(reg, parser), nil
+		parser := spc.NewParserFS(reg, fsys)
+		return spc.NewScannerFS(reg, fsys, parser), nil
 	default:
 		return nil, fmt.Errorf("unsupported package manager: %s", cfg.PackageManager)
 	}
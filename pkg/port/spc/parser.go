@@ -3,8 +3,12 @@ package spc
 import (
 	"bufio"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"portsMaster/pkg/model"
 	"portsMaster/pkg/registry"
@@ -15,11 +19,18 @@ import (
 )
 
 type Parser struct {
-	reg *registry.Registry
+	reg  *registry.Registry
+	fsys fs.FS
 }
 
 func NewParser(reg *registry.Registry) *Parser {
-	return &Parser{reg: reg}
+	return NewParserFS(reg, os.DirFS(reg.PortsRoot()))
+}
+
+// NewParserFS creates a parser reading ports from fsys, whose root is the
+// top of the ports tree.
+func NewParserFS(reg *registry.Registry, fsys fs.FS) *Parser {
+	return &Parser{reg: reg, fsys: fsys}
 }
 
 func (pr *Parser) PortDir(category, name string) string {
@@ -38,49 +49,60 @@ func (pr *Parser) Type() string {
 	return "spc"
 }
 
+// MetadataFile returns the name of the file holding a port's metadata.
+func (pr *Parser) MetadataFile() string {
+	return "info"
+}
+
+// ParseMetadata builds a port from the contents of an info file alone.
+func (pr *Parser) ParseMetadata(category, name string, r io.Reader) (*model.Port, error) {
+	p := &model.Port{
+		Name:     name,
+		Category: category,
+	}
+	if err := parseInfo(p, r); err != nil {
+		return nil, err
+	}
+	p.Upstream = expandUpstream(p)
+	return p, nil
+}
+
 func (pr *Parser) Parse(category, name string) (*model.Port, error) {
-	path := pr.PortDir(category, name)
+	dir := path.Join(category, name)
 
 	p := &model.Port{
 		Name:     name,
 		Category: category,
-		FilePath: path,
+		FilePath: pr.PortDir(category, name),
 	}
 
-	hash, err := calculateDirHash(path)
+	hash, err := calculateDirHash(pr.fsys, dir)
 	if err != nil {
 		return nil, err
 	}
 	p.Hash = hash
 
-	if err := parseInfoFile(p, pr.PortInfoFile(category, name)); err != nil {
+	if err := parseInfoFile(p, pr.fsys, path.Join(dir, "info")); err != nil {
 		return nil, err
 	}
 
-	if err := parseDepsFile(p, pr.PortDepsFile(category, name)); err != nil {
-		if !os.IsNotExist(err) {
+	if err := parseDepsFile(p, pr.fsys, path.Join(dir, "deps")); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
 			return nil, err
 		}
 	}
 
-	p.Upstream = ExpandVariables(p.Upstream, map[string]string{
-		"VERSION":     p.Version,
-		"NAME":        p.Name,
-		"COMMIT":      p.Version, // Often commit is the version
-		"RELEASE":     p.Release,
-		"RELEASE_TAG": p.Release,
-	})
+	p.Upstream = expandUpstream(p)
 
-	recipePath := filepath.Join(path, "ndmake.sh")
-	if lines, err := countLines(recipePath); err == nil {
+	if lines, err := countLines(pr.fsys, path.Join(dir, "ndmake.sh")); err == nil {
 		p.RecipeLines = lines
 	}
 
 	return p, nil
 }
 
-func countLines(path string) (int, error) {
-	f, err := os.Open(path)
+func countLines(fsys fs.FS, name string) (int, error) {
+	f, err := fsys.Open(name)
 	if err != nil {
 		return 0, err
 	}
@@ -94,6 +116,16 @@ func countLines(path string) (int, error) {
 	return count, scanner.Err()
 }
 
+func expandUpstream(p *model.Port) string {
+	return ExpandVariables(p.Upstream, map[string]string{
+		"VERSION":     p.Version,
+		"NAME":        p.Name,
+		"COMMIT":      p.Version, // Often commit is the version
+		"RELEASE":     p.Release,
+		"RELEASE_TAG": p.Release,
+	})
+}
+
 // ExpandVariables replaces ${VAR} in text with values from vars.
 func ExpandVariables(text string, vars map[string]string) string {
 	for k, v := range vars {
@@ -102,14 +134,17 @@ func ExpandVariables(text string, vars map[string]string) string {
 	return text
 }
 
-func parseInfoFile(p *model.Port, path string) error {
-	f, err := os.Open(path)
+func parseInfoFile(p *model.Port, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+	return parseInfo(p, f)
+}
 
-	scanner := bufio.NewScanner(f)
+func parseInfo(p *model.Port, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -152,8 +187,8 @@ func parseInfoFile(p *model.Port, path string) error {
 	return scanner.Err()
 }
 
-func parseDepsFile(p *model.Port, path string) error {
-	f, err := os.Open(path)
+func parseDepsFile(p *model.Port, fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
 	if err != nil {
 		return err
 	}
@@ -189,14 +224,31 @@ func parseDepsFile(p *model.Port, path string) error {
 	return scanner.Err()
 }
 
-func calculateDirHash(dir string) (string, error) {
+// contentHasher is implemented by file infos that already carry a content
+// hash, such as git blobs, which have no meaningful modification time.
+type contentHasher interface {
+	ContentHash() string
+}
+
+func calculateDirHash(fsys fs.FS, dir string) (string, error) {
 	h := blake3.New(32, nil)
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		rel, _ := filepath.Rel(dir, path)
-		fmt.Fprintf(h, "%s|%d|%d;", rel, info.Size(), info.ModTime().UnixNano())
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+		if rel == "" {
+			rel = "."
+		}
+		if ch, ok := info.(contentHasher); ok {
+			fmt.Fprintf(h, "%s|%d|%s;", rel, info.Size(), ch.ContentHash())
+		} else {
+			fmt.Fprintf(h, "%s|%d|%d;", rel, info.Size(), info.ModTime().UnixNano())
+		}
 		return nil
 	})
 	if err != nil {
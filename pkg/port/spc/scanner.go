@@ -2,8 +2,9 @@ package spc
 
 import (
 	"context"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"sync"
 
@@ -14,12 +15,19 @@ import (
 // Scanner implements model.Scanner for the SPC format.
 type Scanner struct {
 	reg    *registry.Registry
+	fsys   fs.FS
 	parser model.Parser
 }
 
-// NewScanner creates a new SPC scanner.
+// NewScanner creates a new SPC scanner over the ports tree on disk.
 func NewScanner(reg *registry.Registry, parser model.Parser) *Scanner {
-	return &Scanner{reg: reg, parser: parser}
+	return NewScannerFS(reg, os.DirFS(reg.PortsRoot()), parser)
+}
+
+// NewScannerFS creates a new SPC scanner over an arbitrary filesystem, such
+// as a git tree. The parser must read from the same filesystem.
+func NewScannerFS(reg *registry.Registry, fsys fs.FS, parser model.Parser) *Scanner {
+	return &Scanner{reg: reg, fsys: fsys, parser: parser}
 }
 
 // Type returns the scanner format type.
@@ -29,7 +37,7 @@ func (s *Scanner) Type() string {
 
 // Scan traverses the ports directory to discover categories and ports.
 func (s *Scanner) Scan(ctx context.Context) ([]*model.Category, []*model.Port, error) {
-	entries, err := os.ReadDir(s.reg.PortsRoot())
+	entries, err := fs.ReadDir(s.fsys, ".")
 	if err != nil {
 		return nil, nil, err
 	}
@@ -57,7 +65,7 @@ func (s *Scanner) Scan(ctx context.Context) ([]*model.Category, []*model.Port, e
 		categories = append(categories, cat)
 		mu.Unlock()
 
-		portEntries, err := os.ReadDir(filepath.Join(s.reg.PortsRoot(), cat.Name))
+		portEntries, err := fs.ReadDir(s.fsys, cat.Name)
 		if err != nil {
 			continue
 		}
@@ -76,7 +84,7 @@ func (s *Scanner) Scan(ctx context.Context) ([]*model.Category, []*model.Port, e
 				}
 
 				// Check for BROKEN file override
-				if _, err := os.Stat(filepath.Join(p.FilePath, "BROKEN")); err == nil {
+				if _, err := fs.Stat(s.fsys, path.Join(catName, portName, "BROKEN")); err == nil {
 					p.IsBroken = true
 				}
 
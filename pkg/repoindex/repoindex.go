@@ -0,0 +1,313 @@
+package repoindex
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"portsMaster/pkg/model"
+
+	"lukechampine.com/blake3"
+)
+
+// Version is the version of both index formats. Clients must reject an
+// index with a version they do not know.
+const Version = 1
+
+// File names of the index, written side by side, each with a detached
+// signature under the same name plus ".sig" when a key is configured.
+const (
+	JSONFile    = "index.json"
+	CompactFile = "index.txt"
+)
+
+// Index is the package repository index. Path is relative to the package
+// root, category/name/file, and is what a client appends to the root to
+// download a package.
+type Index struct {
+	Version   int       `json:"version"`
+	Generated time.Time `json:"generated"`
+	Packages  []Package `json:"packages"`
+}
+
+// Package is one package of the index. Depends lists the run and link
+// dependencies of its port.
+type Package struct {
+	Path     string   `json:"path"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Version  string   `json:"version"`
+	Release  string   `json:"release"`
+	Arch     string   `json:"arch"`
+	Size     int64    `json:"size"`
+	BLAKE3   string   `json:"blake3"`
+	SHA256   string   `json:"sha256"`
+	Depends  []string `json:"depends,omitempty"`
+	Provides []string `json:"provides,omitempty"`
+}
+
+// HostArch returns the architecture of the host under its usual package
+// name, x86_64 rather than Go's amd64.
+func HostArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i686"
+	case "arm":
+		return "armv7"
+	}
+	return runtime.GOARCH
+}
+
+// Build indexes the packages of ports, which ScanPackages found under root.
+// Checksums are taken from sums when the archive is unchanged.
+//
+// Archives carry no metadata of their own, so the version comes from the
+// file name: <name>.spc.<fmt> is the port's current build and
+// <name>-<version>-<release>.spc.<fmt> the build of that version. Archives
+// named otherwise are left out, as are the run and link dependencies of
+// any but the current version.
+func Build(root string, ports []*model.Port, arch string, sums *Checksums, now time.Time) (*Index, error) {
+	ix := &Index{Version: Version, Generated: now.UTC(), Packages: []Package{}}
+	for _, p := range ports {
+		var depends []string
+		for _, d := range p.Deps {
+			if d.Type != model.DepBuild {
+				depends = append(depends, d.Name)
+			}
+		}
+		for _, pkg := range p.Packages {
+			version, release, ok := archiveVersion(p, pkg.Filename)
+			if !ok {
+				continue
+			}
+			current := version == p.Version && release == p.Release
+			rel, err := filepath.Rel(root, pkg.Path)
+			if err != nil {
+				return nil, err
+			}
+			sum, err := sums.Get(pkg.Path)
+			if err != nil {
+				return nil, err
+			}
+			ip := Package{
+				Path:     filepath.ToSlash(rel),
+				Name:     p.Name,
+				Category: p.Category,
+				Version:  version,
+				Release:  release,
+				Arch:     arch,
+				Size:     sum.Size,
+				BLAKE3:   sum.BLAKE3,
+				SHA256:   sum.SHA256,
+			}
+			if current {
+				ip.Depends = depends
+				ip.Provides = p.Provides
+			}
+			ix.Packages = append(ix.Packages, ip)
+		}
+	}
+	sort.Slice(ix.Packages, func(i, j int) bool {
+		return ix.Packages[i].Path < ix.Packages[j].Path
+	})
+	return ix, nil
+}
+
+// archiveVersion returns the version and release of the archive file of
+// p, or false when its name does not tell.
+func archiveVersion(p *model.Port, file string) (string, string, bool) {
+	base, _, ok := strings.Cut(file, ".spc.")
+	if !ok {
+		return "", "", false
+	}
+	if base == p.Name {
+		return p.Version, p.Release, true
+	}
+	rest, ok := strings.CutPrefix(base, p.Name+"-")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, "-")
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// JSON returns the index as indented JSON.
+func (ix *Index) JSON() []byte {
+	b, _ := json.MarshalIndent(ix, "", "  ")
+	return append(b, '\n')
+}
+
+// Compact returns the index in the line format: a "#portsMaster-repo
+// <version> <generated>" header, then one line per package with the
+// tab-separated fields path, name, category, version, release, arch,
+// size, blake3, sha256, depends and provides. Lists are comma-separated,
+// "-" when empty.
+func (ix *Index) Compact() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "#portsMaster-repo %d %s\n", ix.Version, ix.Generated.Format(time.RFC3339))
+	list := func(s []string) string {
+		if len(s) == 0 {
+			return "-"
+		}
+		return strings.Join(s, ",")
+	}
+	for _, p := range ix.Packages {
+		fmt.Fprintf(&buf, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			p.Path, p.Name, p.Category, p.Version, p.Release, p.Arch, p.Size, p.BLAKE3, p.SHA256, list(p.Depends), list(p.Provides))
+	}
+	return buf.Bytes()
+}
+
+// Write writes both formats of the index into dir and, with a key, their
+// detached signatures. Every file is written beside its final name first
+// and then renamed, so clients never read a partial index.
+func (ix *Index) Write(dir string, key ed25519.PrivateKey) error {
+	files := map[string][]byte{
+		JSONFile:    ix.JSON(),
+		CompactFile: ix.Compact(),
+	}
+	if key != nil {
+		for _, name := range []string{JSONFile, CompactFile} {
+			files[name+".sig"] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, files[name])) + "\n")
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tmp := filepath.Join(dir, "."+name+".tmp")
+		if err := os.WriteFile(tmp, files[name], 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSigningKey reads an ed25519 private key from a PEM file holding a
+// PKCS #8 "PRIVATE KEY", as written by `openssl genpkey -algorithm ed25519`.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: no PEM private key", path)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", path)
+	}
+	return key, nil
+}
+
+// Checksum is the size and digests of a package archive.
+type Checksum struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	BLAKE3  string `json:"blake3"`
+	SHA256  string `json:"sha256"`
+}
+
+// Checksums computes the digests of package archives, keeping them in a
+// JSON file until an archive's size or modification time changes.
+type Checksums struct {
+	path string
+	sums map[string]Checksum
+	used map[string]bool
+}
+
+// OpenChecksums loads the checksums stored at path.
+func OpenChecksums(path string) *Checksums {
+	c := &Checksums{path: path, sums: make(map[string]Checksum), used: make(map[string]bool)}
+	if b, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(b, &c.sums)
+	}
+	return c
+}
+
+// Get returns the checksum of the archive at path.
+func (c *Checksums) Get(path string) (Checksum, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+	c.used[path] = true
+	if sum, ok := c.sums[path]; ok && sum.Size == st.Size() && sum.ModTime == st.ModTime().UnixNano() {
+		return sum, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+	defer f.Close()
+	b3 := blake3.New(32, nil)
+	s256 := sha256.New()
+	n, err := io.Copy(io.MultiWriter(b3, s256), f)
+	if err != nil {
+		return Checksum{}, err
+	}
+	if n != st.Size() {
+		return Checksum{}, errors.New(path + ": changed while reading")
+	}
+	sum := Checksum{
+		Size:    n,
+		ModTime: st.ModTime().UnixNano(),
+		BLAKE3:  hex.EncodeToString(b3.Sum(nil)),
+		SHA256:  hex.EncodeToString(s256.Sum(nil)),
+	}
+	c.sums[path] = sum
+	return sum, nil
+}
+
+// Save stores the checksums of the archives looked up since opening.
+func (c *Checksums) Save() error {
+	for path := range c.sums {
+		if !c.used[path] {
+			delete(c.sums, path)
+		}
+	}
+	b, err := json.Marshal(c.sums)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, b, 0644)
+}
@@ -0,0 +1,67 @@
+package repoindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"portsMaster/pkg/model"
+)
+
+func TestBuildVersions(t *testing.T) {
+	root := t.TempDir()
+	p := &model.Port{
+		Category: "core",
+		Name:     "zlib-ng",
+		Version:  "2.2.1",
+		Release:  "2",
+		Deps:     []model.Dependency{{Name: "musl", Type: model.DepLink}, {Name: "cmake", Type: model.DepBuild}},
+	}
+	for _, name := range []string{
+		"zlib-ng.spc.tar.zst",
+		"zlib-ng-2.1.0-1.spc.tar.zst",
+		"zlib-ng-2.2.1-2.spc.tar.xz",
+		"zlib-ng-compat.spc.tar.zst",
+		"zlib-ng-2.0.spc.tar.zst",
+	} {
+		path := filepath.Join(root, p.Category, p.Name, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p.Packages = append(p.Packages, model.PackageInfo{Filename: name, Path: path})
+	}
+
+	ix, err := Build(root, []*model.Port{p}, "x86_64", OpenChecksums(filepath.Join(t.TempDir(), "sums.json")), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]string{
+		"core/zlib-ng/zlib-ng.spc.tar.zst":         {"2.2.1", "2"},
+		"core/zlib-ng/zlib-ng-2.1.0-1.spc.tar.zst": {"2.1.0", "1"},
+		"core/zlib-ng/zlib-ng-2.2.1-2.spc.tar.xz":  {"2.2.1", "2"},
+	}
+	if len(ix.Packages) != len(want) {
+		t.Fatalf("indexed %d packages, want %d: %+v", len(ix.Packages), len(want), ix.Packages)
+	}
+	for _, pkg := range ix.Packages {
+		v, ok := want[pkg.Path]
+		if !ok {
+			t.Errorf("unexpected package %s", pkg.Path)
+			continue
+		}
+		if pkg.Version != v[0] || pkg.Release != v[1] {
+			t.Errorf("%s: version %s-%s, want %s-%s", pkg.Path, pkg.Version, pkg.Release, v[0], v[1])
+		}
+		current := pkg.Version == p.Version && pkg.Release == p.Release
+		if current != (len(pkg.Depends) == 1 && pkg.Depends[0] == "musl") {
+			t.Errorf("%s: depends %v", pkg.Path, pkg.Depends)
+		}
+		if pkg.Size != int64(len(filepath.Base(pkg.Path))) || len(pkg.SHA256) != 64 || len(pkg.BLAKE3) != 64 {
+			t.Errorf("%s: size %d, sha256 %q, blake3 %q", pkg.Path, pkg.Size, pkg.SHA256, pkg.BLAKE3)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package source
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/util"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// blameCacheVersion is bumped whenever the cached blame changes shape.
+const blameCacheVersion = "1"
+
+type cachedBlame struct {
+	Version     string             `json:"version"`
+	Path        string             `json:"path"`
+	HeadHash    string             `json:"head"`
+	MailmapHash string             `json:"mailmap"`
+	Hunks       []*model.BlameHunk `json:"hunks"`
+}
+
+// BlameCached returns the blame of path at the provider's revision. Results
+// are stored per file under cacheDir/blame and reused while the revision
+// and mailmap stay the same.
+func (g *GitProvider) BlameCached(path, cacheDir string) ([]*model.BlameHunk, error) {
+	head, err := g.resolve(g.rev)
+	if err != nil {
+		return nil, err
+	}
+	currentHead := head.Hash.String()
+
+	cachePath := filepath.Join(cacheDir, "blame", util.Slugify(g.rev), util.Slugify(path)+".json")
+	if b, err := os.ReadFile(cachePath); err == nil {
+		var cache cachedBlame
+		if json.Unmarshal(b, &cache) == nil && cache.Version == blameCacheVersion && cache.Path == path && cache.HeadHash == currentHead && cache.MailmapHash == g.mailmap.Hash() {
+			return cache.Hunks, nil
+		}
+	}
+
+	hunks, err := g.Blame(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = os.MkdirAll(filepath.Dir(cachePath), 0755)
+	if b, err := json.Marshal(cachedBlame{
+		Version:     blameCacheVersion,
+		Path:        path,
+		HeadHash:    currentHead,
+		MailmapHash: g.mailmap.Hash(),
+		Hunks:       hunks,
+	}); err == nil {
+		_ = os.WriteFile(cachePath, b, 0644)
+	}
+	return hunks, nil
+}
+
+// BlobHash returns the hash of the blob path holds at the provider's
+// revision.
+func (g *GitProvider) BlobHash(path string) (string, error) {
+	head, err := g.resolve(g.rev)
+	if err != nil {
+		return "", err
+	}
+	f, err := head.File(path)
+	if err != nil {
+		return "", err
+	}
+	return f.Hash.String(), nil
+}
+
+// MailmapHash fingerprints the identity mapping applied to commit authors.
+func (g *GitProvider) MailmapHash() string {
+	return g.mailmap.Hash()
+}
+
+// Blame attributes every line of path at the provider's revision to the
+// commit that last changed it, grouping consecutive lines of one commit.
+func (g *GitProvider) Blame(path string) ([]*model.BlameHunk, error) {
+	head, err := g.resolve(g.rev)
+	if err != nil {
+		return nil, err
+	}
+	res, err := git.Blame(head, path)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make(map[string]*model.Commit)
+	var hunks []*model.BlameHunk
+	for i, l := range res.Lines {
+		hash := l.Hash.String()
+		if n := len(hunks); n > 0 && hunks[n-1].Commit.Hash == hash {
+			hunks[n-1].Lines = append(hunks[n-1].Lines, l.Text)
+			continue
+		}
+
+		mc, ok := commits[hash]
+		if !ok {
+			name, email := g.mailmap.Resolve(l.AuthorName, l.Author)
+			mc = &model.Commit{Hash: hash, Author: name, Email: email, Date: l.Date}
+			if c, err := g.repo.CommitObject(l.Hash); err == nil {
+				mc.Message = strings.TrimSpace(c.Message)
+			}
+			commits[hash] = mc
+		}
+		hunks = append(hunks, &model.BlameHunk{Commit: mc, Start: i + 1, Lines: []string{l.Text}})
+	}
+	return hunks, nil
+}
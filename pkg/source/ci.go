@@ -1,6 +1,7 @@
 package source
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,23 +12,9 @@ import (
 
 // LoadCIStatus reads a JSON file mapping "category/name" to CIInfo.
 func LoadCIStatus(path string) (map[string]*model.CIInfo, error) {
-	var rc io.ReadCloser
-	if len(path) > 4 && path[:4] == "http" {
-		resp, err := http.Get(path)
-		if err != nil {
-			return nil, err
-		}
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			return nil, fmt.Errorf("failed to fetch CI status: %s", resp.Status)
-		}
-		rc = resp.Body
-	} else {
-		f, err := os.Open(path)
-		if err != nil {
-			return nil, err
-		}
-		rc = f
+	rc, err := Open(context.Background(), path)
+	if err != nil {
+		return nil, err
 	}
 	defer rc.Close()
 
@@ -37,3 +24,23 @@ func LoadCIStatus(path string) (map[string]*model.CIInfo, error) {
 	}
 	return data, nil
 }
+
+// Open returns the content of a local file or an http(s) URL.
+func Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if len(path) > 4 && path[:4] == "http" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to fetch %s: %s", path, resp.Status)
+		}
+		return resp.Body, nil
+	}
+	return os.Open(path)
+}
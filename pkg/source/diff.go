@@ -0,0 +1,134 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"portsMaster/pkg/model"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitChanges returns the tree changes of a commit against its first
+// parent, or against an empty tree for a root commit.
+func (g *GitProvider) commitChanges(hash string) (object.Changes, error) {
+	c, err := g.repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, err
+	}
+	cTree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var pTree *object.Tree
+	if parent, err := c.Parent(0); err == nil {
+		if pTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	} else if c.NumParents() > 0 {
+		return nil, fmt.Errorf("parent of %s is missing from the shallow clone", hash)
+	}
+	return object.DiffTree(pTree, cTree)
+}
+
+// ChangedFiles lists the paths a commit touches without reading any blobs.
+func (g *GitProvider) ChangedFiles(hash string) ([]string, error) {
+	changes, err := g.commitChanges(hash)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		name := ch.To.Name
+		if name == "" {
+			name = ch.From.Name
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// Diff renders the unified diff of a commit against its first parent.
+// Once maxBytes of diff text or maxFiles files have been produced, the
+// remaining files are only listed in Omitted.
+func (g *GitProvider) Diff(hash string, maxBytes, maxFiles int) (*model.CommitDiff, error) {
+	changes, err := g.commitChanges(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &model.CommitDiff{}
+	budget := maxBytes
+	for _, ch := range changes {
+		name := ch.To.Name
+		if name == "" {
+			name = ch.From.Name
+		}
+		if budget <= 0 || len(out.Files) >= maxFiles {
+			out.Omitted = append(out.Omitted, name)
+			continue
+		}
+
+		action, _ := ch.Action()
+		fd := &model.FileDiff{Path: name, Action: strings.ToLower(action.String())}
+		out.Files = append(out.Files, fd)
+
+		patch, err := ch.Patch()
+		if err != nil {
+			return nil, err
+		}
+		if fps := patch.FilePatches(); len(fps) > 0 && fps[0].IsBinary() {
+			fd.Binary = true
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := diff.NewUnifiedEncoder(&buf, diff.DefaultContextLines).Encode(patch); err != nil {
+			return nil, err
+		}
+		budget = parseUnified(fd, &buf, budget)
+	}
+	return out, nil
+}
+
+// parseUnified classifies the lines of an encoded patch, dropping the file
+// header, and returns what is left of the byte budget.
+func parseUnified(fd *model.FileDiff, buf *bytes.Buffer, budget int) int {
+	inHunks := false
+	sc := bufio.NewScanner(buf)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "@@") {
+			inHunks = true
+		}
+		if !inHunks || line == "" {
+			continue
+		}
+		if budget <= 0 {
+			fd.Truncated = true
+			break
+		}
+		budget -= len(line) + 1
+
+		var kind model.DiffLineKind
+		switch line[0] {
+		case '@':
+			kind = model.DiffHunk
+		case '+':
+			kind = model.DiffAdd
+			fd.Added++
+		case '-':
+			kind = model.DiffDelete
+			fd.Deleted++
+		default:
+			kind = model.DiffContext
+		}
+		fd.Lines = append(fd.Lines, model.DiffLine{Kind: kind, Text: line})
+	}
+	return budget
+}
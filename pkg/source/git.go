@@ -6,15 +6,21 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"portsMaster/pkg/model"
+	"portsMaster/pkg/util"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 type GitProvider struct {
-	repo *git.Repository
+	repo    *git.Repository
+	mailmap *Mailmap
+	keyring *Keyring
+	rev     string
 }
 
 func NewGitProvider(path string) (*GitProvider, error) {
@@ -25,67 +31,132 @@ func NewGitProvider(path string) (*GitProvider, error) {
 	return &GitProvider{repo: r}, nil
 }
 
-type cachedGitData struct {
-	HeadHash         string                        `json:"head"`
+// RepositoryData holds everything extracted from a walk of the git history.
+type RepositoryData struct {
 	PortCommits      map[string][]*model.Commit    `json:"port_commits"`
 	RecentCommits    []*model.Commit               `json:"recent"`
 	ContributorStats map[string]*model.Contributor `json:"contributors"`
+	RemovedPorts     []*model.RemovedPort          `json:"removed"`
+	FileCommits      map[string]string             `json:"file_commits"`
+}
+
+// historyCacheVersion is bumped whenever RepositoryData changes shape.
+const historyCacheVersion = "4"
+
+type cachedGitData struct {
+	Version     string `json:"version"`
+	HeadHash    string `json:"head"`
+	MailmapHash string `json:"mailmap"`
+	KeyringHash string `json:"keyring,omitempty"`
+	RepositoryData
+}
+
+// At returns a provider whose history walks start at rev instead of HEAD.
+func (g *GitProvider) At(rev string) *GitProvider {
+	return &GitProvider{repo: g.repo, mailmap: g.mailmap, keyring: g.keyring, rev: rev}
+}
+
+// resolve returns the commit rev points to; an empty rev means HEAD.
+func (g *GitProvider) resolve(rev string) (*object.Commit, error) {
+	if rev == "" {
+		ref, err := g.repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		return g.repo.CommitObject(ref.Hash())
+	}
+	h, err := g.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return g.repo.CommitObject(*h)
+}
+
+// SetMailmap installs the identity mapping applied to commit authors.
+func (g *GitProvider) SetMailmap(m *Mailmap) {
+	g.mailmap = m
+}
+
+// SetKeyring enables signature verification of every walked commit.
+func (g *GitProvider) SetKeyring(k *Keyring) {
+	g.keyring = k
 }
 
-func (g *GitProvider) GetRepositoryDataCached(ports []*model.Port, cacheDir string) (map[string][]*model.Commit, []*model.Commit, map[string]*model.Contributor, error) {
-	ref, err := g.repo.Head()
+func (g *GitProvider) GetRepositoryDataCached(ports []*model.Port, cacheDir string) (*RepositoryData, error) {
+	head, err := g.resolve(g.rev)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
-	currentHead := ref.Hash().String()
+	currentHead := head.Hash.String()
 
 	cachePath := filepath.Join(cacheDir, "git_history.json")
+	if g.rev != "" {
+		cachePath = filepath.Join(cacheDir, "git_history_"+util.Slugify(g.rev)+".json")
+	}
 	if f, err := os.Open(cachePath); err == nil {
 		var cache cachedGitData
 		if err := json.NewDecoder(f).Decode(&cache); err == nil {
 			f.Close()
-			if cache.HeadHash == currentHead {
-				return cache.PortCommits, cache.RecentCommits, cache.ContributorStats, nil
+			if cache.Version == historyCacheVersion && cache.HeadHash == currentHead && cache.MailmapHash == g.mailmap.Hash() && cache.KeyringHash == g.keyring.Hash() {
+				return &cache.RepositoryData, nil
 			}
 		} else {
 			f.Close()
 		}
 	}
 
-	m, r, stats, err := g.GetRepositoryData(ports)
+	data, err := g.GetRepositoryData(ports)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
 	_ = os.MkdirAll(cacheDir, 0755)
 	if f, err := os.Create(cachePath); err == nil {
 		cache := cachedGitData{
-			HeadHash:         currentHead,
-			PortCommits:      m,
-			RecentCommits:    r,
-			ContributorStats: stats,
+			Version:        historyCacheVersion,
+			HeadHash:       currentHead,
+			MailmapHash:    g.mailmap.Hash(),
+			KeyringHash:    g.keyring.Hash(),
+			RepositoryData: *data,
 		}
 		_ = json.NewEncoder(f).Encode(cache)
 		f.Close()
 	}
 
-	return m, r, stats, nil
+	return data, nil
 }
 
-func (g *GitProvider) GetRepositoryData(ports []*model.Port) (map[string][]*model.Commit, []*model.Commit, map[string]*model.Contributor, error) {
-	ref, err := g.repo.Head()
+// ReadFile returns the contents of path as it was at the given revision.
+func (g *GitProvider) ReadFile(rev, path string) ([]byte, error) {
+	c, err := g.repo.CommitObject(plumbing.NewHash(rev))
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.File(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := f.Contents()
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
+	return []byte(content), nil
+}
 
-	cIter, err := g.repo.Log(&git.LogOptions{From: ref.Hash()})
+func (g *GitProvider) GetRepositoryData(ports []*model.Port) (*RepositoryData, error) {
+	head, err := g.resolve(g.rev)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
+	cIter := g.log(head)
+
 	portCommits := make(map[string][]*model.Commit)
+	fileCommits := make(map[string]string)
 	contributorStats := make(map[string]*model.Contributor)
 	var recentCommits []*model.Commit
+	var removedPorts []*model.RemovedPort
+	removed := make(map[string]bool)
 
 	interested := make(map[string]bool)
 	for _, p := range ports {
@@ -95,38 +166,78 @@ func (g *GitProvider) GetRepositoryData(ports []*model.Port) (map[string][]*mode
 	const recentLimit = 100
 	count := 0
 
+	// credit records activity for an identity without counting a commit.
+	credit := func(name, rawEmail string, when time.Time) *model.Contributor {
+		email := strings.ToLower(strings.TrimSpace(rawEmail))
+		if email == "" {
+			email = "unknown"
+		}
+
+		stats, ok := contributorStats[email]
+		if !ok {
+			stats = &model.Contributor{Name: name, Email: email}
+			contributorStats[email] = stats
+		}
+		if stats.LastCommit.IsZero() || when.After(stats.LastCommit) {
+			stats.LastCommit = when
+		}
+		if stats.FirstCommit.IsZero() || when.Before(stats.FirstCommit) {
+			stats.FirstCommit = when
+		}
+		if stats.Daily == nil {
+			stats.Daily = make(map[string]int)
+		}
+		stats.Daily[when.Format("2006-01-02")]++
+		if name != stats.Name {
+			found := false
+			for _, n := range stats.OtherNames {
+				if n == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				stats.OtherNames = append(stats.OtherNames, name)
+			}
+		}
+		return stats
+	}
+
 	err = cIter.ForEach(func(c *object.Commit) error {
-		                email := strings.ToLower(strings.TrimSpace(c.Author.Email))
-		                if email == "" {
-		                        email = "unknown"
-		                }
-		
-		                stats, ok := contributorStats[email]
-		                if !ok {
-		                        stats = &model.Contributor{Name: c.Author.Name, Email: email}
-		                        contributorStats[email] = stats
-		                }
-		                stats.Count++
-		                if c.Author.Name != stats.Name {
-		                        found := false
-		                        for _, n := range stats.OtherNames {
-		                                if n == c.Author.Name {
-		                                        found = true
-		                                        break
-		                                }
-		                        }
-		                        if !found {
-		                                stats.OtherNames = append(stats.OtherNames, c.Author.Name)
-		                        }
-		                }
+		name, rawEmail := g.mailmap.Resolve(c.Author.Name, c.Author.Email)
+		stats := credit(name, rawEmail, c.Author.When)
+		stats.Count++
+
 		mc := &model.Commit{
 			Hash:    c.Hash.String(),
-			Author:  c.Author.Name,
-			Email:   c.Author.Email,
+			Author:  name,
+			Email:   rawEmail,
 			Date:    c.Author.When,
 			Message: strings.TrimSpace(c.Message),
 			IsMerge: c.NumParents() > 1,
 		}
+		applyTrailers(mc, g.mailmap)
+		if g.keyring != nil {
+			mc.Signature, mc.SignedBy = g.keyring.Verify(c)
+			if stats.Signatures == nil {
+				stats.Signatures = make(map[string]int)
+			}
+			stats.Signatures[string(mc.Signature)]++
+		}
+
+		// Contributors are keyed by email, so co-authors without one are
+		// listed on the commit but not credited.
+		credited := []*model.Contributor{stats}
+		for _, co := range mc.CoAuthors {
+			if strings.TrimSpace(co.Email) == "" {
+				continue
+			}
+			cs := credit(co.Name, co.Email, c.Author.When)
+			if cs != stats {
+				cs.CoAuthored++
+				credited = append(credited, cs)
+			}
+		}
 
 		if count < recentLimit {
 			recentCommits = append(recentCommits, mc)
@@ -134,11 +245,12 @@ func (g *GitProvider) GetRepositoryData(ports []*model.Port) (map[string][]*mode
 
 		parent, _ := c.Parent(0)
 		var changes object.Changes
+		var pTree, cTree *object.Tree
 		if parent != nil {
-			pTree, _ := parent.Tree()
-			cTree, _ := c.Tree()
+			pTree, _ = parent.Tree()
+			cTree, _ = c.Tree()
 			changes, _ = pTree.Diff(cTree)
-		} else {
+		} else if c.NumParents() == 0 {
 			cTree, _ := c.Tree()
 			_ = cTree.Files().ForEach(func(f *object.File) error {
 				changes = append(changes, &object.Change{To: object.ChangeEntry{Name: f.Name}})
@@ -168,9 +280,27 @@ func (g *GitProvider) GetRepositoryData(ports []*model.Port) (map[string][]*mode
 			parts := strings.Split(file, "/")
 			if len(parts) >= 2 {
 				key := parts[0] + "/" + parts[1]
+				if _, ok := fileCommits[file]; interested[key] && !ok {
+					fileCommits[file] = mc.Hash
+				}
 				if interested[key] && !seenInCommit[key] {
 					seenInCommit[key] = true
 					portCommits[key] = append(portCommits[key], mc)
+					for _, cs := range credited {
+						if cs.Ports == nil {
+							cs.Ports = make(map[string]int)
+						}
+						cs.Ports[key]++
+					}
+				}
+				if len(parts) >= 3 && !interested[key] && !removed[key] && isDirRemoval(pTree, cTree, key) {
+					removed[key] = true
+					removedPorts = append(removedPorts, &model.RemovedPort{
+						Category: parts[0],
+						Name:     parts[1],
+						LastHash: parent.Hash.String(),
+						Commit:   mc,
+					})
 				}
 			}
 		}
@@ -180,8 +310,41 @@ func (g *GitProvider) GetRepositoryData(ports []*model.Port) (map[string][]*mode
 	})
 
 	if err != nil && err.Error() != "done" {
-		return nil, nil, nil, err
+		return nil, err
+	}
+
+	return &RepositoryData{
+		PortCommits:      portCommits,
+		RecentCommits:    recentCommits,
+		ContributorStats: contributorStats,
+		RemovedPorts:     removedPorts,
+		FileCommits:      fileCommits,
+	}, nil
+}
+
+// log walks the history from c in pre-order. In a shallow clone the walk
+// stops at the boundary commits instead of failing on their missing parents.
+func (g *GitProvider) log(c *object.Commit) object.CommitIter {
+	var ignore []plumbing.Hash
+	if shallow, err := g.repo.Storer.Shallow(); err == nil {
+		for _, h := range shallow {
+			if sc, err := g.repo.CommitObject(h); err == nil {
+				ignore = append(ignore, sc.ParentHashes...)
+			}
+		}
 	}
+	return object.NewCommitPreorderIter(c, nil, ignore)
+}
 
-	return portCommits, recentCommits, contributorStats, nil
+// isDirRemoval reports whether dir existed in the parent tree but is gone
+// from the commit tree, i.e. the whole directory was deleted.
+func isDirRemoval(pTree, cTree *object.Tree, dir string) bool {
+	if pTree == nil || cTree == nil || strings.HasPrefix(dir, ".") {
+		return false
+	}
+	if _, err := pTree.Tree(dir); err != nil {
+		return false
+	}
+	_, err := cTree.Tree(dir)
+	return err != nil
 }
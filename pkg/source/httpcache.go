@@ -0,0 +1,113 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"portsMaster/pkg/cache"
+)
+
+// ErrNotFound is returned by HTTPCache.Get for a 404 response.
+var ErrNotFound = errors.New("not found")
+
+// httpTimeout bounds every request of the default client, so a stalled
+// server cannot hang a build.
+const httpTimeout = time.Minute
+
+// maxBodyBytes bounds the size of a fetched document. Indexes and listings
+// are far smaller; a larger body is rejected rather than read into memory.
+const maxBodyBytes = 64 << 20
+
+type cachedResponse struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// HTTPCache fetches URLs with conditional requests, keeping every body
+// with its ETag and Last-Modified validators under dir so that unchanged
+// documents are answered by a 304 and read from disk.
+type HTTPCache struct {
+	dir    string
+	client *http.Client
+}
+
+// NewHTTPCache returns a cache under dir using client, or a client that
+// gives up after httpTimeout when nil.
+func NewHTTPCache(dir string, client *http.Client) *HTTPCache {
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+	return &HTTPCache{dir: dir, client: client}
+}
+
+func (c *HTTPCache) path(url string) string {
+	return filepath.Join(c.dir, cache.HashString(url)+".json")
+}
+
+// Get returns the body of url, revalidating a cached copy.
+func (c *HTTPCache) Get(ctx context.Context, url string) ([]byte, error) {
+	var cached *cachedResponse
+	if b, err := os.ReadFile(c.path(url)); err == nil {
+		var r cachedResponse
+		if json.Unmarshal(b, &r) == nil && r.URL == url {
+			cached = &r
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.Body, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBodyBytes {
+		return nil, fmt.Errorf("failed to fetch %s: larger than %d bytes", url, maxBodyBytes)
+	}
+
+	r := cachedResponse{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         body,
+	}
+	if r.ETag != "" || r.LastModified != "" {
+		_ = os.MkdirAll(c.dir, 0755)
+		if b, err := json.Marshal(r); err == nil {
+			_ = os.WriteFile(c.path(url), b, 0644)
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,99 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPCacheRevalidates(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		validator string
+		value     string
+		condition string
+	}{
+		{"etag", "ETag", `"v1"`, "If-None-Match"},
+		{"last-modified", "Last-Modified", "Sun, 18 Oct 2026 12:00:00 GMT", "If-Modified-Since"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var full, notModified atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tc.condition) == tc.value {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full.Add(1)
+				w.Header().Set(tc.validator, tc.value)
+				w.Write([]byte("document"))
+			}))
+			defer srv.Close()
+
+			hc := NewHTTPCache(t.TempDir(), srv.Client())
+			for i := range 3 {
+				body, err := hc.Get(context.Background(), srv.URL+"/index.json")
+				if err != nil {
+					t.Fatalf("get %d: %v", i, err)
+				}
+				if string(body) != "document" {
+					t.Errorf("get %d: body %q, want %q", i, body, "document")
+				}
+			}
+			if full.Load() != 1 || notModified.Load() != 2 {
+				t.Errorf("served %d full and %d not modified responses, want 1 and 2", full.Load(), notModified.Load())
+			}
+		})
+	}
+}
+
+func TestHTTPCacheWithoutValidators(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Errorf("conditional request without validators")
+		}
+		w.Write([]byte("document"))
+	}))
+	defer srv.Close()
+
+	hc := NewHTTPCache(t.TempDir(), srv.Client())
+	for range 2 {
+		if _, err := hc.Get(context.Background(), srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Errorf("served %d requests, want 2", requests.Load())
+	}
+}
+
+func TestHTTPCacheErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", maxBodyBytes+1)))
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	hc := NewHTTPCache(t.TempDir(), srv.Client())
+	if _, err := hc.Get(context.Background(), srv.URL+"/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("404: got %v, want ErrNotFound", err)
+	}
+	if _, err := hc.Get(context.Background(), srv.URL+"/broken"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("500: got %v, want an error other than ErrNotFound", err)
+	}
+	if _, err := hc.Get(context.Background(), srv.URL+"/large"); err == nil {
+		t.Errorf("oversized body: got no error")
+	}
+}
@@ -0,0 +1,170 @@
+package source
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"portsMaster/pkg/cache"
+)
+
+type identity struct {
+	Name  string
+	Email string
+}
+
+// Mailmap canonicalizes author identities following git's .mailmap rules.
+// Lookups are case-insensitive on both name and email. Aliases added with
+// AddAlias are kept apart and checked before any .mailmap entry.
+type Mailmap struct {
+	byEmail     map[string]identity
+	byNameEmail map[string]identity
+
+	aliasByEmail map[string]identity
+	aliasByName  map[string]identity
+}
+
+// NewMailmap returns an empty mailmap that maps every identity to itself.
+func NewMailmap() *Mailmap {
+	return &Mailmap{
+		byEmail:     make(map[string]identity),
+		byNameEmail: make(map[string]identity),
+
+		aliasByEmail: make(map[string]identity),
+		aliasByName:  make(map[string]identity),
+	}
+}
+
+// LoadMailmap reads a .mailmap file. A missing file yields an empty mailmap.
+func LoadMailmap(path string) (*Mailmap, error) {
+	m := NewMailmap()
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return m, m.Parse(f)
+}
+
+// Parse adds the entries of a .mailmap formatted reader. Supported forms are:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func (m *Mailmap) Parse(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		m.parseLine(line)
+	}
+	return sc.Err()
+}
+
+func (m *Mailmap) parseLine(line string) {
+	var names, emails []string
+	for {
+		open := strings.Index(line, "<")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(line[open:], ">")
+		if end < 0 {
+			break
+		}
+		names = append(names, strings.TrimSpace(line[:open]))
+		emails = append(emails, strings.TrimSpace(line[open+1:open+end]))
+		line = line[open+end+1:]
+	}
+
+	switch len(emails) {
+	case 1:
+		m.Add(names[0], emails[0], "", emails[0])
+	case 2:
+		m.Add(names[0], emails[0], names[1], emails[1])
+	}
+}
+
+// Add maps the commit identity to the proper one. An empty commitName
+// matches any name used with commitEmail. Empty proper fields keep the
+// commit's value.
+func (m *Mailmap) Add(properName, properEmail, commitName, commitEmail string) {
+	id := identity{Name: properName, Email: properEmail}
+	key := strings.ToLower(commitEmail)
+	switch {
+	case commitName != "":
+		m.byNameEmail[strings.ToLower(commitName)+"\x00"+key] = id
+	case commitEmail != "":
+		m.byEmail[key] = id
+	}
+}
+
+// AddAlias maps every identity using commitEmail, or when commitEmail is
+// empty every identity named commitName, to the proper one. Aliases take
+// precedence over the entries added with Add or Parse.
+func (m *Mailmap) AddAlias(properName, properEmail, commitName, commitEmail string) {
+	id := identity{Name: properName, Email: properEmail}
+	switch {
+	case commitEmail != "":
+		m.aliasByEmail[strings.ToLower(commitEmail)] = id
+	case commitName != "":
+		m.aliasByName[strings.ToLower(commitName)] = id
+	}
+}
+
+// Resolve returns the canonical name and email for a commit identity.
+func (m *Mailmap) Resolve(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+	key := strings.ToLower(strings.TrimSpace(email))
+	id, ok := m.aliasByEmail[key]
+	if !ok {
+		id, ok = m.aliasByName[strings.ToLower(name)]
+	}
+	if !ok {
+		id, ok = m.byNameEmail[strings.ToLower(name)+"\x00"+key]
+	}
+	if !ok {
+		id, ok = m.byEmail[key]
+	}
+	if !ok {
+		return name, email
+	}
+	if id.Name != "" {
+		name = id.Name
+	}
+	if id.Email != "" {
+		email = id.Email
+	}
+	return name, email
+}
+
+// Hash fingerprints the mailmap so cached history can be invalidated when
+// the identity mapping changes.
+func (m *Mailmap) Hash() string {
+	if m == nil {
+		return ""
+	}
+	h := cache.NewHasher()
+	for _, table := range []map[string]identity{m.byEmail, m.byNameEmail, m.aliasByEmail, m.aliasByName} {
+		keys := make([]string, 0, len(table))
+		for k := range table {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			h.Add(k + "\x00" + table[k].Name + "\x00" + table[k].Email + ";")
+		}
+		h.Add("|")
+	}
+	return h.Sum()
+}
@@ -17,7 +17,7 @@ func ScanPackages(reg *registry.Registry, ports []*model.Port) error {
 		return nil
 	}
 
-	// Check if PkgsRoot is remote (starts with http). If so, we can't scan it with os.ReadDir.
+	// Remote package roots are read by ScanRemotePackages.
 	if len(pkgsRoot) > 4 && (pkgsRoot[:4] == "http" || pkgsRoot[:4] == "ftp:") {
 		return nil
 	}
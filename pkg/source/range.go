@@ -0,0 +1,132 @@
+package source
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"portsMaster/pkg/model"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Tag is a git tag resolved to the commit it points to.
+type Tag struct {
+	Name string
+	Hash string
+	Date time.Time
+}
+
+// Tags lists the repository's tags, lightweight or annotated, oldest first.
+func (g *GitProvider) Tags() ([]Tag, error) {
+	iter, err := g.repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []Tag
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		c, err := g.resolve(ref.Name().String())
+		if err != nil {
+			return nil
+		}
+		tags = append(tags, Tag{Name: ref.Name().Short(), Hash: c.Hash.String(), Date: c.Committer.When})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].Date.Equal(tags[j].Date) {
+			return tags[i].Date.Before(tags[j].Date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// Revision resolves rev to its commit hash and commit date.
+func (g *GitProvider) Revision(rev string) (string, time.Time, error) {
+	c, err := g.resolve(rev)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return c.Hash.String(), c.Committer.When, nil
+}
+
+// CommitsBetween returns the commits reachable from to but not from from,
+// newest first, grouped by the "category/name" port directories they touch.
+// An empty from selects the whole history of to.
+func (g *GitProvider) CommitsBetween(from, to string) (map[string][]*model.Commit, error) {
+	head, err := g.resolve(to)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make(map[plumbing.Hash]bool)
+	if from != "" {
+		base, err := g.resolve(from)
+		if err != nil {
+			return nil, err
+		}
+		_ = g.log(base).ForEach(func(c *object.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		})
+	}
+
+	out := make(map[string][]*model.Commit)
+	err = g.log(head).ForEach(func(c *object.Commit) error {
+		if exclude[c.Hash] {
+			return nil
+		}
+		name, email := g.mailmap.Resolve(c.Author.Name, c.Author.Email)
+		mc := &model.Commit{
+			Hash:    c.Hash.String(),
+			Author:  name,
+			Email:   email,
+			Date:    c.Author.When,
+			Message: strings.TrimSpace(c.Message),
+			IsMerge: c.NumParents() > 1,
+		}
+		applyTrailers(mc, g.mailmap)
+
+		cTree, err := c.Tree()
+		if err != nil {
+			return nil
+		}
+		var pTree *object.Tree
+		if parent, err := c.Parent(0); err == nil {
+			pTree, _ = parent.Tree()
+		} else if c.NumParents() > 0 {
+			// Shallow clone boundary: the changes are unknown.
+			return nil
+		}
+		changes, err := object.DiffTree(pTree, cTree)
+		if err != nil {
+			return nil
+		}
+
+		seen := make(map[string]bool)
+		for _, ch := range changes {
+			file := ch.To.Name
+			if file == "" {
+				file = ch.From.Name
+			}
+			parts := strings.Split(file, "/")
+			if len(parts) < 3 || strings.HasPrefix(parts[0], ".") {
+				continue
+			}
+			key := parts[0] + "/" + parts[1]
+			if !seen[key] {
+				seen[key] = true
+				out[key] = append(out[key], mc)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
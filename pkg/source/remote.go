@@ -0,0 +1,151 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// SyncRepository clones url into dir, or fetches it when dir already holds
+// a clone, and checks out the tip of branch (the remote's default branch
+// when empty). Every fetch names its refspecs explicitly: the branch and
+// each of refs, the extra refs rendered next to it, so a changed branch or
+// a newly configured ref is picked up by the next fetch. Refs under
+// refs/heads/ are kept under the same name; tags are always fetched. A
+// positive depth makes the clone shallow. It reports whether the checked
+// out commit or any of refs changed.
+func SyncRepository(url, dir, branch string, refs []string, depth int) (bool, error) {
+	cloned := false
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return false, err
+		}
+		opts := &git.CloneOptions{
+			URL:          url,
+			SingleBranch: true,
+			Depth:        depth,
+			Tags:         git.AllTags,
+		}
+		if branch != "" {
+			opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		}
+		if _, err := git.PlainClone(dir, false, opts); err != nil {
+			os.RemoveAll(dir)
+			return false, fmt.Errorf("failed to clone %s: %w", url, err)
+		}
+		if len(refs) == 0 {
+			return true, nil
+		}
+		cloned = true
+	}
+
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to open git repo at %s: %w", dir, err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		return false, err
+	}
+
+	specs, err := fetchRefSpecs(r, branch, refs)
+	if err != nil {
+		return false, err
+	}
+	before := refHashes(r, refs)
+	err = r.Fetch(&git.FetchOptions{RemoteName: "origin", RefSpecs: specs, Depth: depth, Tags: git.AllTags, Force: true})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return false, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	changed := cloned || refHashes(r, refs) != before
+
+	// A single-branch clone of the default branch tracks the remote HEAD.
+	name := plumbing.NewRemoteHEADReferenceName("origin")
+	if branch != "" {
+		name = plumbing.NewRemoteReferenceName("origin", branch)
+	}
+	remote, err := r.Reference(name, true)
+	if err != nil && branch == "" {
+		name = plumbing.NewRemoteReferenceName("origin", head.Name().Short())
+		remote, err = r.Reference(name, true)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find %s of %s: %w", name.Short(), url, err)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return false, err
+	}
+	// A changed branch is checked out under its own name.
+	if local := plumbing.NewBranchReferenceName(branch); branch != "" && head.Name() != local {
+		if err := r.Storer.SetReference(plumbing.NewHashReference(local, remote.Hash())); err != nil {
+			return false, err
+		}
+		if err := w.Checkout(&git.CheckoutOptions{Branch: local, Force: true}); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if remote.Hash() == head.Hash() {
+		return changed, nil
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: remote.Hash(), Mode: git.HardReset}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fetchRefSpecs returns the refspecs of branch, or of the remote's default
+// branch when empty, and of every ref under refs/heads/ or origin/.
+func fetchRefSpecs(r *git.Repository, branch string, refs []string) ([]gitconfig.RefSpec, error) {
+	var specs []gitconfig.RefSpec
+	if branch != "" {
+		specs = append(specs, remoteBranchSpec(branch))
+	} else {
+		rem, err := r.Remote("origin")
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, rem.Config().Fetch...)
+	}
+	for _, ref := range refs {
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			specs = append(specs, gitconfig.RefSpec("+"+ref+":"+ref))
+		case strings.HasPrefix(ref, "refs/remotes/origin/"):
+			specs = append(specs, remoteBranchSpec(strings.TrimPrefix(ref, "refs/remotes/origin/")))
+		case strings.HasPrefix(ref, "origin/"):
+			specs = append(specs, remoteBranchSpec(strings.TrimPrefix(ref, "origin/")))
+		}
+	}
+	for _, s := range specs {
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid refspec %s: %w", s, err)
+		}
+	}
+	return specs, nil
+}
+
+func remoteBranchSpec(branch string) gitconfig.RefSpec {
+	return gitconfig.RefSpec("+refs/heads/" + branch + ":refs/remotes/origin/" + branch)
+}
+
+// refHashes fingerprints the commits refs resolve to, so a fetch that only
+// moves an extra ref still counts as a change.
+func refHashes(r *git.Repository, refs []string) string {
+	var b strings.Builder
+	for _, ref := range refs {
+		if h, err := r.ResolveRevision(plumbing.Revision(ref)); err == nil {
+			b.WriteString(h.String())
+		}
+		b.WriteByte(';')
+	}
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"portsMaster/pkg/model"
+)
+
+// RemoteIndex is the package index published at the root of a remote
+// package repository, such as the one `portsMaster repo index` writes.
+// Paths are relative to the root and follow the local layout,
+// category/name/package.spc.<fmt>.
+type RemoteIndex struct {
+	Version  int             `json:"version"`
+	Packages []RemotePackage `json:"packages"`
+}
+
+// remoteIndexVersion is the only RemoteIndex version understood, the
+// repoindex.Version written by `portsMaster repo index`.
+const remoteIndexVersion = 1
+
+// RemotePackage is one package of a RemoteIndex.
+type RemotePackage struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ScanRemotePackages augments ports with the packages of the http(s)
+// package root at root. It reads the index file named index under the root
+// and, when the root has none or index is empty, walks the autoindex HTML
+// listings of the root, its category and its port directories. Every
+// document is fetched through hc.
+func ScanRemotePackages(ctx context.Context, hc *HTTPCache, root, index string, ports []*model.Port) error {
+	if !strings.HasPrefix(root, "http://") && !strings.HasPrefix(root, "https://") {
+		return fmt.Errorf("unsupported package root %s: only http and https are supported", root)
+	}
+	root = strings.TrimSuffix(root, "/")
+
+	portMap := make(map[string]*model.Port, len(ports))
+	for _, p := range ports {
+		portMap[p.Category+"/"+p.Name] = p
+	}
+	attach := func(cat, name, file string, size int64) {
+		p, ok := portMap[cat+"/"+name]
+		if !ok || !strings.Contains(file, ".spc.") {
+			return
+		}
+		u := root + "/" + url.PathEscape(cat) + "/" + url.PathEscape(name) + "/" + url.PathEscape(file)
+		p.Packages = append(p.Packages, model.PackageInfo{Filename: file, Path: u, URL: u, Size: size})
+	}
+
+	if index != "" {
+		body, err := hc.Get(ctx, root+"/"+index)
+		if err == nil {
+			var idx RemoteIndex
+			if err := json.Unmarshal(body, &idx); err != nil {
+				return fmt.Errorf("failed to parse %s/%s: %w", root, index, err)
+			}
+			if idx.Version != remoteIndexVersion {
+				return fmt.Errorf("%s/%s: unsupported index version %d", root, index, idx.Version)
+			}
+			for _, pkg := range idx.Packages {
+				if parts := strings.Split(strings.TrimPrefix(pkg.Path, "/"), "/"); len(parts) == 3 {
+					attach(parts[0], parts[1], parts[2], pkg.Size)
+				}
+			}
+			return nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	cats, err := listRemoteDir(ctx, hc, root+"/")
+	if err != nil {
+		return err
+	}
+	categories := make(map[string]bool)
+	for _, p := range ports {
+		categories[p.Category] = true
+	}
+	for _, cat := range cats {
+		if !cat.dir || !categories[cat.name] {
+			continue
+		}
+		names, err := listRemoteDir(ctx, hc, root+"/"+url.PathEscape(cat.name)+"/")
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if !name.dir || portMap[cat.name+"/"+name.name] == nil {
+				continue
+			}
+			files, err := listRemoteDir(ctx, hc, root+"/"+url.PathEscape(cat.name)+"/"+url.PathEscape(name.name)+"/")
+			if err != nil {
+				continue
+			}
+			for _, f := range files {
+				if !f.dir {
+					attach(cat.name, name.name, f.name, f.size)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// remoteEntry is an entry of a directory listing. Size is 0 when the
+// listing does not show it.
+type remoteEntry struct {
+	name string
+	dir  bool
+	size int64
+}
+
+func listRemoteDir(ctx context.Context, hc *HTTPCache, u string) ([]remoteEntry, error) {
+	body, err := hc.Get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return parseListing(string(body)), nil
+}
+
+var (
+	anchorRe = regexp.MustCompile(`(?i)<a\s[^>]*href="([^"]*)"[^>]*>.*?</a>`)
+	tagRe    = regexp.MustCompile(`<[^>]*>`)
+	sizeRe   = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)([KMGT]?)i?B?$`)
+)
+
+// parseListing reads the entries of an autoindex page as served by nginx,
+// Apache or lighttpd: relative links to the directory's own children, one
+// row per line, with the size as the last column after the link. Links to
+// parents, other sites and sort orders are skipped.
+func parseListing(body string) []remoteEntry {
+	var entries []remoteEntry
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(body, "\n") {
+		locs := anchorRe.FindAllStringSubmatchIndex(line, -1)
+		for i, loc := range locs {
+			href := strings.TrimPrefix(html.UnescapeString(line[loc[2]:loc[3]]), "./")
+			if href == "" || strings.ContainsAny(href[:1], "?#/.") || strings.Contains(href, "://") {
+				continue
+			}
+			name, err := url.PathUnescape(href)
+			if err != nil {
+				continue
+			}
+			e := remoteEntry{name: strings.TrimSuffix(name, "/"), dir: strings.HasSuffix(name, "/")}
+			if e.name == "" || strings.Contains(e.name, "/") || seen[e.name] {
+				continue
+			}
+			if !e.dir {
+				end := len(line)
+				if i+1 < len(locs) {
+					end = locs[i+1][0]
+				}
+				e.size = parseListingSize(html.UnescapeString(tagRe.ReplaceAllString(line[loc[1]:end], " ")))
+			}
+			seen[e.name] = true
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// parseListingSize reads the last column of a listing row, either exact
+// bytes or a size like 1.2M in binary units.
+func parseListingSize(rest string) int64 {
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return 0
+	}
+	m := sizeRe.FindStringSubmatch(fields[len(fields)-1])
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToUpper(m[2]) {
+	case "K":
+		n *= 1 << 10
+	case "M":
+		n *= 1 << 20
+	case "G":
+		n *= 1 << 30
+	case "T":
+		n *= 1 << 40
+	}
+	return int64(n)
+}
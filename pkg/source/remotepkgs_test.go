@@ -0,0 +1,187 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"portsMaster/pkg/model"
+)
+
+const nginxListing = `<html>
+<head><title>Index of /core/zlib/</title></head>
+<body>
+<h1>Index of /core/zlib/</h1><hr><pre><a href="../">../</a>
+<a href="patches/">patches/</a>                                           18-Oct-2026 17:00                   -
+<a href="zlib.spc.tar.zst">zlib.spc.tar.zst</a>                                   18-Oct-2026 17:00              123456
+<a href="zlib%2Bextra.spc.tar.xz">zlib+extra.spc.tar.xz</a>                              18-Oct-2026 17:00                 512
+</pre><hr></body>
+</html>
+`
+
+const apacheListing = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">
+<html>
+ <head>
+  <title>Index of /core/zlib</title>
+ </head>
+ <body>
+<h1>Index of /core/zlib</h1>
+  <table>
+   <tr><th valign="top"><img src="/icons/blank.gif" alt="[ICO]"></th><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th><th><a href="?C=S;O=A">Size</a></th><th><a href="?C=D;O=A">Description</a></th></tr>
+   <tr><th colspan="5"><hr></th></tr>
+<tr><td valign="top"><img src="/icons/back.gif" alt="[PARENTDIR]"></td><td><a href="/core/">Parent Directory</a></td><td>&nbsp;</td><td align="right">  - </td><td>&nbsp;</td></tr>
+<tr><td valign="top"><img src="/icons/folder.gif" alt="[DIR]"></td><td><a href="patches/">patches/</a></td><td align="right">2026-10-18 17:00  </td><td align="right">  - </td><td>&nbsp;</td></tr>
+<tr><td valign="top"><img src="/icons/compressed.gif" alt="[   ]"></td><td><a href="zlib.spc.tar.zst">zlib.spc.tar.zst</a></td><td align="right">2026-10-18 17:00  </td><td align="right">1.2M</td><td>&nbsp;</td></tr>
+<tr><td valign="top"><img src="/icons/compressed.gif" alt="[   ]"></td><td><a href="https://example.org/elsewhere.spc.tar.zst">mirror</a></td><td align="right">2026-10-18 17:00  </td><td align="right">4K</td><td>&nbsp;</td></tr>
+   <tr><th colspan="5"><hr></th></tr>
+</table>
+</body></html>
+`
+
+func TestParseListing(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		want []remoteEntry
+	}{
+		{"nginx", nginxListing, []remoteEntry{
+			{name: "patches", dir: true},
+			{name: "zlib.spc.tar.zst", size: 123456},
+			{name: "zlib+extra.spc.tar.xz", size: 512},
+		}},
+		{"apache", apacheListing, []remoteEntry{
+			{name: "patches", dir: true},
+			{name: "zlib.spc.tar.zst", size: 1258291},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseListing(tc.body); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseListingSize(t *testing.T) {
+	for rest, want := range map[string]int64{
+		"18-Oct-2026 17:00              123456": 123456,
+		"2026-10-18 17:00   1.2M":               1258291,
+		"2026-10-18 17:00   4K":                 4096,
+		"2026-10-18 17:00   2.5GiB":             2684354560,
+		"2026-10-18 17:00   -":                  0,
+		"":                                      0,
+	} {
+		if got := parseListingSize(rest); got != want {
+			t.Errorf("parseListingSize(%q) = %d, want %d", rest, got, want)
+		}
+	}
+}
+
+func testPorts() []*model.Port {
+	return []*model.Port{
+		{Category: "core", Name: "zlib"},
+		{Category: "net", Name: "curl"},
+	}
+}
+
+func packageURLs(ports []*model.Port) map[string][]string {
+	out := make(map[string][]string)
+	for _, p := range ports {
+		for _, pkg := range p.Packages {
+			out[p.Category+"/"+p.Name] = append(out[p.Category+"/"+p.Name], pkg.URL)
+		}
+	}
+	return out
+}
+
+func TestScanRemotePackagesIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repo/index.json" {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"version": 1, "packages": [
+			{"path": "core/zlib/zlib.spc.tar.zst", "size": 100},
+			{"path": "net/curl/curl.spc.tar.xz", "size": 200},
+			{"path": "core/gone/gone.spc.tar.zst", "size": 300},
+			{"path": "core/zlib/README", "size": 10}
+		]}`))
+	}))
+	defer srv.Close()
+
+	ports := testPorts()
+	hc := NewHTTPCache(t.TempDir(), srv.Client())
+	if err := ScanRemotePackages(context.Background(), hc, srv.URL+"/repo/", "index.json", ports); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"core/zlib": {srv.URL + "/repo/core/zlib/zlib.spc.tar.zst"},
+		"net/curl":  {srv.URL + "/repo/net/curl/curl.spc.tar.xz"},
+	}
+	if got := packageURLs(ports); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if size := ports[1].Packages[0].Size; size != 200 {
+		t.Errorf("curl size = %d, want 200", size)
+	}
+}
+
+func TestScanRemotePackagesListing(t *testing.T) {
+	listings := map[string]string{
+		"/":           `<a href="../">../</a>` + "\n" + `<a href="core/">core/</a>` + "\n" + `<a href="extra/">extra/</a>` + "\n",
+		"/core/":      `<a href="zlib/">zlib/</a>` + "\n" + `<a href="musl/">musl/</a>` + "\n",
+		"/core/zlib/": nginxListing,
+		"/extra/":     `<a href="curl/">curl/</a>` + "\n",
+		"/core/musl/": `<a href="musl.spc.tar.zst">musl.spc.tar.zst</a> 1K` + "\n",
+		"/net/curl/":  `<a href="curl.spc.tar.xz">curl.spc.tar.xz</a> 1K` + "\n",
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		body, ok := listings[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	ports := testPorts()
+	hc := NewHTTPCache(t.TempDir(), srv.Client())
+	if err := ScanRemotePackages(context.Background(), hc, srv.URL, "index.json", ports); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"core/zlib": {
+			srv.URL + "/core/zlib/zlib.spc.tar.zst",
+			srv.URL + "/core/zlib/zlib+extra.spc.tar.xz",
+		},
+	}
+	if got := packageURLs(ports); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	wantRequests := []string{"/index.json", "/", "/core/", "/core/zlib/"}
+	if !reflect.DeepEqual(requested, wantRequests) {
+		t.Errorf("requested %v, want %v", requested, wantRequests)
+	}
+}
+
+func TestScanRemotePackagesIndexVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version": 2, "packages": [{"path": "core/zlib/zlib.spc.tar.zst", "size": 100}]}`))
+	}))
+	defer srv.Close()
+
+	ports := testPorts()
+	hc := NewHTTPCache(t.TempDir(), srv.Client())
+	if err := ScanRemotePackages(context.Background(), hc, srv.URL, "index.json", ports); err == nil {
+		t.Error("got no error for an index of version 2")
+	}
+	if got := packageURLs(ports); len(got) != 0 {
+		t.Errorf("attached %v from an unsupported index", got)
+	}
+}
@@ -0,0 +1,220 @@
+package source
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"portsMaster/pkg/cache"
+	"portsMaster/pkg/model"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// Keyring holds the public keys commit signatures are verified against.
+type Keyring struct {
+	pgp  openpgp.EntityList
+	ssh  map[string]ssh.PublicKey
+	hash string
+}
+
+// LoadKeyring reads every key file in dir: OpenPGP keys (*.asc, *.gpg,
+// *.pgp, armored or binary) and SSH public keys (*.pub and allowed_signers
+// files, one key per line).
+func LoadKeyring(dir string) (*Keyring, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{ssh: make(map[string]ssh.PublicKey)}
+	h := cache.NewHasher()
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch ext := filepath.Ext(e.Name()); {
+		case ext == ".asc" || ext == ".gpg" || ext == ".pgp":
+			el, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(content))
+			if err != nil {
+				el, err = openpgp.ReadKeyRing(bytes.NewReader(content))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+			}
+			k.pgp = append(k.pgp, el...)
+		case ext == ".pub" || e.Name() == "allowed_signers":
+			k.addSSHKeys(content)
+		default:
+			continue
+		}
+		h.Add(e.Name())
+		h.AddBytes(content)
+	}
+	k.hash = h.Sum()
+	return k, nil
+}
+
+// addSSHKeys parses authorized_keys or allowed_signers formatted lines. The
+// principals column of allowed_signers is skipped.
+func (k *Keyring) addSSHKeys(content []byte) {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			if _, rest, ok := strings.Cut(line, " "); ok {
+				pub, _, _, _, err = ssh.ParseAuthorizedKey([]byte(rest))
+			}
+		}
+		if err == nil {
+			k.ssh[ssh.FingerprintSHA256(pub)] = pub
+		}
+	}
+}
+
+// Hash fingerprints the keyring so cached verification results can be
+// invalidated when keys are added or revoked.
+func (k *Keyring) Hash() string {
+	if k == nil {
+		return ""
+	}
+	return k.hash
+}
+
+// Verify checks the signature of c and returns its status along with a
+// description of the signing key.
+func (k *Keyring) Verify(c *object.Commit) (model.SignatureStatus, string) {
+	if c.PGPSignature == "" {
+		return model.SignatureUnsigned, ""
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return model.SignatureBad, ""
+	}
+	r, err := encoded.Reader()
+	if err != nil {
+		return model.SignatureBad, ""
+	}
+	signed, err := io.ReadAll(r)
+	if err != nil {
+		return model.SignatureBad, ""
+	}
+
+	sig := strings.TrimSpace(c.PGPSignature)
+	switch {
+	case strings.HasPrefix(sig, "-----BEGIN SSH SIGNATURE-----"):
+		return k.verifySSH(signed, sig)
+	case strings.HasPrefix(sig, "-----BEGIN PGP SIGNATURE-----"):
+		return k.verifyPGP(signed, sig)
+	default:
+		return model.SignatureUnknownKey, ""
+	}
+}
+
+func (k *Keyring) verifyPGP(signed []byte, sig string) (model.SignatureStatus, string) {
+	signer, err := openpgp.CheckArmoredDetachedSignature(k.pgp, bytes.NewReader(signed), strings.NewReader(sig), nil)
+	if errors.Is(err, pgperrors.ErrUnknownIssuer) {
+		return model.SignatureUnknownKey, ""
+	}
+	if err != nil || signer == nil {
+		return model.SignatureBad, ""
+	}
+	if id := signer.PrimaryIdentity(); id != nil {
+		return model.SignatureValid, id.Name
+	}
+	return model.SignatureValid, strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint))
+}
+
+// sshSignature is the blob of an armored SSH signature, following the
+// "SSHSIG" magic preamble.
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is what the key actually signs: the hash of the message
+// wrapped with the namespace and algorithm.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      []byte
+	HashAlgorithm string
+	Hash          []byte
+}
+
+const sshSigMagic = "SSHSIG"
+
+func (k *Keyring) verifySSH(signed []byte, armored string) (model.SignatureStatus, string) {
+	block, _ := pem.Decode([]byte(armored))
+	if block == nil || block.Type != "SSH SIGNATURE" || !bytes.HasPrefix(block.Bytes, []byte(sshSigMagic)) {
+		return model.SignatureBad, ""
+	}
+	var blob sshSignature
+	if err := ssh.Unmarshal(block.Bytes[len(sshSigMagic):], &blob); err != nil || blob.Version != 1 {
+		return model.SignatureBad, ""
+	}
+	pub, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return model.SignatureBad, ""
+	}
+	fp := ssh.FingerprintSHA256(pub)
+	known, ok := k.ssh[fp]
+	if !ok {
+		return model.SignatureUnknownKey, fp
+	}
+	if blob.Namespace != "git" {
+		return model.SignatureBad, fp
+	}
+
+	var h hash.Hash
+	switch blob.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return model.SignatureBad, fp
+	}
+	h.Write(signed)
+
+	var s ssh.Signature
+	if err := ssh.Unmarshal(blob.Signature, &s); err != nil {
+		return model.SignatureBad, fp
+	}
+	data := append([]byte(sshSigMagic), ssh.Marshal(sshSignedData{
+		Namespace:     blob.Namespace,
+		Reserved:      blob.Reserved,
+		HashAlgorithm: blob.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	if err := known.Verify(data, &s); err != nil {
+		return model.SignatureBad, fp
+	}
+	return model.SignatureValid, fp
+}
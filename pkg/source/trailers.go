@@ -0,0 +1,63 @@
+package source
+
+import (
+	"regexp"
+	"strings"
+
+	"portsMaster/pkg/model"
+)
+
+var trailerRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.+)$`)
+
+// applyTrailers parses the trailer block, the last paragraph of the message
+// if every line in it is a "Key: value" pair, into the commit's structured
+// fields. Recognized trailers are removed from the message; unknown ones stay.
+func applyTrailers(mc *model.Commit, mm *Mailmap) {
+	msg := mc.Message
+	i := strings.LastIndex(msg, "\n\n")
+	if i < 0 {
+		return
+	}
+	block := strings.Split(msg[i+2:], "\n")
+	for _, line := range block {
+		if !trailerRegex.MatchString(strings.TrimSpace(line)) {
+			return
+		}
+	}
+
+	var kept []string
+	for _, line := range block {
+		m := trailerRegex.FindStringSubmatch(strings.TrimSpace(line))
+		val := strings.TrimSpace(m[2])
+		switch strings.ToLower(m[1]) {
+		case "co-authored-by":
+			mc.CoAuthors = append(mc.CoAuthors, ParsePerson(val, mm))
+		case "reviewed-by":
+			mc.ReviewedBy = append(mc.ReviewedBy, ParsePerson(val, mm))
+		case "signed-off-by":
+			mc.SignedOffBy = append(mc.SignedOffBy, ParsePerson(val, mm))
+		case "fixes":
+			mc.Fixes = append(mc.Fixes, val)
+		default:
+			kept = append(kept, line)
+		}
+	}
+
+	mc.Message = strings.TrimSpace(msg[:i])
+	if len(kept) > 0 {
+		mc.Message += "\n\n" + strings.Join(kept, "\n")
+	}
+}
+
+// ParsePerson splits "Name <email>" and canonicalizes it through the mailmap.
+func ParsePerson(s string, mm *Mailmap) model.Person {
+	name, email := s, ""
+	if open := strings.Index(s, "<"); open >= 0 {
+		if end := strings.Index(s[open:], ">"); end >= 0 {
+			name = strings.TrimSpace(s[:open])
+			email = strings.TrimSpace(s[open+1 : open+end])
+		}
+	}
+	name, email = mm.Resolve(name, email)
+	return model.Person{Name: name, Email: email}
+}
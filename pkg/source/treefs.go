@@ -0,0 +1,189 @@
+package source
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// TreeFS exposes a git tree as a read-only fs.FS so that scanners can read a
+// revision straight from git objects without a checkout.
+type TreeFS struct {
+	s    storer.EncodedObjectStorer
+	tree *object.Tree
+	when time.Time
+}
+
+// TreeFS resolves rev (a branch, tag or commit hash) and returns its tree.
+func (g *GitProvider) TreeFS(rev string) (*TreeFS, error) {
+	c, err := g.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return &TreeFS{s: g.repo.Storer, tree: tree, when: c.Committer.When}, nil
+}
+
+func (t *TreeFS) Open(name string) (fs.File, error) {
+	info, err := t.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries, err := t.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &treeDir{info: info, entries: entries}, nil
+	}
+	blob, err := object.GetBlob(t.s, info.hash)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &treeFile{info: info, r: r}, nil
+}
+
+func (t *TreeFS) Stat(name string) (fs.FileInfo, error) {
+	return t.stat("stat", name)
+}
+
+func (t *TreeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	dir := t.tree
+	if name != "." {
+		if !fs.ValidPath(name) {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+		}
+		sub, err := t.tree.Tree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+		dir = sub
+	}
+
+	out := make([]fs.DirEntry, 0, len(dir.Entries))
+	for _, e := range dir.Entries {
+		out = append(out, &treeEntry{fs: t, entry: e})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+func (t *TreeFS) stat(op, name string) (*treeInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &treeInfo{name: ".", mode: filemode.Dir, hash: t.tree.Hash, when: t.when}, nil
+	}
+	e, err := t.tree.FindEntry(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return t.info(e)
+}
+
+func (t *TreeFS) info(e *object.TreeEntry) (*treeInfo, error) {
+	ti := &treeInfo{name: path.Base(e.Name), mode: e.Mode, hash: e.Hash, when: t.when}
+	if e.Mode == filemode.Regular || e.Mode == filemode.Executable || e.Mode == filemode.Symlink {
+		size, err := t.s.EncodedObjectSize(e.Hash)
+		if err != nil {
+			return nil, err
+		}
+		ti.size = size
+	}
+	return ti, nil
+}
+
+type treeInfo struct {
+	name string
+	mode filemode.FileMode
+	hash plumbing.Hash
+	size int64
+	when time.Time
+}
+
+func (i *treeInfo) Name() string       { return i.name }
+func (i *treeInfo) Size() int64        { return i.size }
+func (i *treeInfo) ModTime() time.Time { return i.when }
+func (i *treeInfo) IsDir() bool        { return i.mode == filemode.Dir }
+func (i *treeInfo) Sys() any           { return nil }
+
+// ContentHash returns the git object hash, which changes exactly when the
+// content does.
+func (i *treeInfo) ContentHash() string { return i.hash.String() }
+
+func (i *treeInfo) Mode() fs.FileMode {
+	switch i.mode {
+	case filemode.Dir:
+		return fs.ModeDir | 0555
+	case filemode.Executable:
+		return 0555
+	case filemode.Symlink:
+		return fs.ModeSymlink | 0444
+	default:
+		return 0444
+	}
+}
+
+type treeEntry struct {
+	fs    *TreeFS
+	entry object.TreeEntry
+}
+
+func (e *treeEntry) Name() string      { return e.entry.Name }
+func (e *treeEntry) IsDir() bool       { return e.entry.Mode == filemode.Dir }
+func (e *treeEntry) Type() fs.FileMode { return (&treeInfo{mode: e.entry.Mode}).Mode().Type() }
+
+func (e *treeEntry) Info() (fs.FileInfo, error) {
+	return e.fs.info(&e.entry)
+}
+
+type treeFile struct {
+	info *treeInfo
+	r    io.ReadCloser
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *treeFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *treeFile) Close() error               { return f.r.Close() }
+
+type treeDir struct {
+	info    *treeInfo
+	entries []fs.DirEntry
+}
+
+func (d *treeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		out := d.entries
+		d.entries = nil
+		return out, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	out := d.entries[:n]
+	d.entries = d.entries[n:]
+	return out, nil
+}
+
+func (d *treeDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *treeDir) Close() error               { return nil }
+func (d *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
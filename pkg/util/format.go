@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"portsMaster/pkg/model"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -90,3 +91,44 @@ func Plural(n int, unit string) string {
 	}
 	return fmt.Sprintf("%d %ss", n, unit)
 }
+
+// CompareVersions orders two version strings segment by segment, comparing
+// digit runs numerically and everything else lexically. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	for a != "" || b != "" {
+		sa, ra := nextVersionSegment(a)
+		sb, rb := nextVersionSegment(b)
+		a, b = ra, rb
+
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case sa != sb:
+			if sa < sb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func nextVersionSegment(v string) (string, string) {
+	v = strings.TrimLeft(v, ".-_+~")
+	if v == "" {
+		return "", ""
+	}
+	digit := v[0] >= '0' && v[0] <= '9'
+	i := 0
+	for i < len(v) && !strings.ContainsRune(".-_+~", rune(v[i])) && (v[i] >= '0' && v[i] <= '9') == digit {
+		i++
+	}
+	return v[:i], v[i:]
+}
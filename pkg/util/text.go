@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strings"
 
 	"lukechampine.com/blake3"
 )
@@ -29,3 +30,11 @@ var mdLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
 func StripMarkdownLinks(text string) string {
 	return mdLinkRegex.ReplaceAllString(text, "$1")
 }
+
+var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify lowercases text and collapses everything but letters and digits
+// into single dashes, for use in URLs.
+func Slugify(text string) string {
+	return strings.Trim(slugRegex.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
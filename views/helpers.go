@@ -1,9 +1,14 @@
 package views
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 
+	"portsMaster/pkg/config"
+	"portsMaster/pkg/model"
+	"portsMaster/pkg/util"
+
 	"github.com/a-h/templ"
 )
 
@@ -39,3 +44,32 @@ func Href(current, target string) templ.SafeURL {
 	rel = filepath.ToSlash(rel)
 	return templ.SafeURL(rel + query)
 }
+
+// CommitHref links to the static page of a commit when one was generated and
+// to the commit on the source forge otherwise.
+func CommitHref(current string, c *model.Commit, data *model.SiteData, cfg *config.Config) templ.SafeURL {
+	if data != nil && data.CommitPages[c.Hash] != nil {
+		return Href(current, "/commits/"+c.Hash+"/index.html")
+	}
+	return templ.SafeURL(fmt.Sprintf("%s/commit/%s", cfg.SourceCodeURL, c.Hash))
+}
+
+// ContributorHref links to the profile page of the contributor with the
+// given email, or returns an empty URL when there is none.
+func ContributorHref(current string, data *model.SiteData, email string) templ.SafeURL {
+	if data == nil {
+		return ""
+	}
+	if c, ok := data.ContributorStats[strings.ToLower(strings.TrimSpace(email))]; ok && c.Slug != "" {
+		return Href(current, "/contributors/"+c.Slug+"/index.html")
+	}
+	return ""
+}
+
+// HeatLevel buckets a daily count into one of five intensity levels.
+func HeatLevel(count, max int) string {
+	if count <= 0 || max <= 0 {
+		return "heat-0"
+	}
+	return fmt.Sprintf("heat-%d", 1+util.Min(3, (count-1)*4/max))
+}
@@ -9,6 +9,7 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
+	"encoding/json"
 	"portsMaster/pkg/config"
 	"portsMaster/pkg/model"
 )
@@ -53,49 +54,62 @@ func Search(data *model.SiteData, cfg *config.Config, currentPath string) templ.
 			var templ_7745c5c3_Var3 templ.SafeURL
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinURLErrs(Href(currentPath, "/"))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 11, Col: 35}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 12, Col: 35}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\">home</a> / search</div><div class=\"section-header\">Advanced Search</div><form class=\"search-form\" id=\"searchForm\"><div class=\"search-row\"><label for=\"query\">Search Query</label> <input type=\"text\" id=\"query\" name=\"query\" placeholder=\"enter search terms or filter expression...\"><div class=\"search-examples\"><code>curl</code> - simple text search<br><code>name:git AND category:devel</code> - logical operators<br><code>provides:/usr/bin/vim</code> - file path search<br><code>depends:libressl OR depends:openssl</code> - dependency search<br><code>NOT category:devel</code> - negation<br><code>is:broken</code> - find build failures<br><code>is:new</code> - updated in last 30 days<br><code>since:7d</code> - updated in last 7 days<br><code>description:\"web server\"</code> - exact phrase match</div></div><div class=\"filter-group\"><div class=\"filter-group-header\">Search In</div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_name\" checked> <label for=\"search_name\">Port Name</label></div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_desc\" checked> <label for=\"search_desc\">Description</label></div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_files\"> <label for=\"search_files\">File Paths (provides)</label></div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_deps\"> <label for=\"search_deps\">Dependencies</label></div></div><div class=\"filter-group\"><div class=\"filter-group-header\">Filters</div><div class=\"search-row\"><label>Category</label> <select name=\"category\" id=\"filter-category\"><option value=\"\">any</option> ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\">home</a> / search</div><div class=\"section-header\">Advanced Search</div><form class=\"search-form\" id=\"searchForm\" data-statuses=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(ciStatusesJSON(cfg))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 17, Col: 79}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\"><div class=\"search-row\"><label for=\"query\">Search Query</label> <input type=\"text\" id=\"query\" name=\"query\" placeholder=\"enter search terms or filter expression...\"><div class=\"search-examples\"><code>curl</code> - simple text search<br><code>name:git AND category:devel</code> - logical operators<br><code>provides:/usr/bin/vim</code> - file path search<br><code>file:/usr/bin/vim</code> - which package ships a file (or <code>file:vim</code>)<br><code>soname:libz.so.1</code> - which port provides a shared library<br><code>depends:libressl OR depends:openssl</code> - dependency search<br><code>NOT category:devel</code> - negation<br><code>is:broken</code> - find build failures<br><code>is:regressed</code> - build time or size regressions<br><code>status:skipped</code> - CI status, or kind: success, failure, pending, neutral<br><code>is:new</code> - updated in last 30 days<br><code>since:7d</code> - updated in last 7 days<br><code>description:\"web server\"</code> - exact phrase match</div></div><div class=\"filter-group\"><div class=\"filter-group-header\">Search In</div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_name\" checked> <label for=\"search_name\">Port Name</label></div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_desc\" checked> <label for=\"search_desc\">Description</label></div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_files\"> <label for=\"search_files\">File Paths (provides)</label></div><div class=\"filter-option\"><input type=\"checkbox\" id=\"search_deps\"> <label for=\"search_deps\">Dependencies</label></div></div><div class=\"filter-group\"><div class=\"filter-group-header\">Filters</div><div class=\"search-row\"><label>Category</label> <select name=\"category\" id=\"filter-category\"><option value=\"\">any</option> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			for _, c := range data.Categories {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<option value=\"")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<option value=\"")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var4 string
-				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(c.Name)
+				var templ_7745c5c3_Var5 string
+				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(c.Name)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 59, Col: 29}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 64, Col: 29}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var5 string
-				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(c.Name)
+				var templ_7745c5c3_Var6 string
+				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(c.Name)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 59, Col: 40}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/search.templ`, Line: 64, Col: 40}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</option>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</option>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</select></div></div><div class=\"button-row\"><input type=\"submit\" value=\"search\"> <input type=\"reset\" value=\"clear\"></div></form><div id=\"results\" class=\"results display-none\"><div class=\"result-stats\">Found <strong id=\"resultCount\">0</strong> ports matching query: <code id=\"queryDisplay\"></code> (search took <span id=\"searchTime\">0</span>ms)</div><table id=\"resultsTable\"><thead><tr><th>Port</th><th>Version</th><th>Category</th><th>Description</th></tr></thead> <tbody id=\"resultsBody\"></tbody></table></div><div class=\"section-header\">Filter Syntax Reference</div><table><thead><tr><th>Filter</th><th>Description</th><th>Example</th></tr></thead> <tbody><tr><td><code>name:TERM</code></td><td>Search in port name</td><td><code>name:curl</code></td></tr><tr><td><code>description:TERM</code></td><td>Search in description</td><td><code>description:editor</code></td></tr><tr><td><code>category:CAT</code></td><td>Filter by category</td><td><code>category:network</code></td></tr><tr><td><code>provides:PATH</code></td><td>Find package providing file</td><td><code>provides:/usr/bin/git</code></td></tr><tr><td><code>depends:PKG</code></td><td>Find packages depending on PKG</td><td><code>depends:musl</code></td></tr><tr><td><code>AND</code></td><td>Logical AND operator</td><td><code>name:lib AND category:system</code></td></tr><tr><td><code>OR</code></td><td>Logical OR operator</td><td><code>category:audio OR category:video</code></td></tr><tr><td><code>NOT</code></td><td>Logical NOT operator</td><td><code>NOT license:GPL</code></td></tr><tr><td><code>\"phrase\"</code></td><td>Exact phrase match</td><td><code>description:\"text editor\"</code></td></tr></tbody></table>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</select></div></div><div class=\"button-row\"><input type=\"submit\" value=\"search\"> <input type=\"reset\" value=\"clear\"></div></form><div id=\"results\" class=\"results display-none\"><div class=\"result-stats\">Found <strong id=\"resultCount\">0</strong> ports matching query: <code id=\"queryDisplay\"></code> (search took <span id=\"searchTime\">0</span>ms)</div><table id=\"resultsTable\"><thead><tr><th>Port</th><th>Version</th><th>Category</th><th>Description</th></tr></thead> <tbody id=\"resultsBody\"></tbody></table></div><div class=\"section-header\">Filter Syntax Reference</div><table><thead><tr><th>Filter</th><th>Description</th><th>Example</th></tr></thead> <tbody><tr><td><code>name:TERM</code></td><td>Search in port name</td><td><code>name:curl</code></td></tr><tr><td><code>description:TERM</code></td><td>Search in description</td><td><code>description:editor</code></td></tr><tr><td><code>category:CAT</code></td><td>Filter by category</td><td><code>category:network</code></td></tr><tr><td><code>provides:PATH</code></td><td>Find package providing file</td><td><code>provides:/usr/bin/git</code></td></tr><tr><td><code>depends:PKG</code></td><td>Find packages depending on PKG</td><td><code>depends:musl</code></td></tr><tr><td><code>AND</code></td><td>Logical AND operator</td><td><code>name:lib AND category:system</code></td></tr><tr><td><code>OR</code></td><td>Logical OR operator</td><td><code>category:audio OR category:video</code></td></tr><tr><td><code>NOT</code></td><td>Logical NOT operator</td><td><code>NOT license:GPL</code></td></tr><tr><td><code>\"phrase\"</code></td><td>Exact phrase match</td><td><code>description:\"text editor\"</code></td></tr></tbody></table>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -109,4 +123,10 @@ func Search(data *model.SiteData, cfg *config.Config, currentPath string) templ.
 	})
 }
 
+// ciStatusesJSON hands the status vocabulary to search.js.
+func ciStatusesJSON(cfg *config.Config) string {
+	b, _ := json.Marshal(cfg.CI.Vocabulary)
+	return string(b)
+}
+
 var _ = templruntime.GeneratedTemplate